@@ -0,0 +1,132 @@
+// Command coordinator drives the networked multi-party signing protocol:
+// it holds the unsigned PSBT but none of the cosigners' keys, streams it
+// to each cosigner endpoint over mTLS, collects back their partial
+// signatures, merges them and finalizes the spend.
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+
+	"github.com/halseth/multisig-check/cosignproto"
+	ourpsbt "github.com/halseth/multisig-check/psbt"
+)
+
+type arrayFlags []string
+
+// String is an implementation of the flag.Value interface
+func (i *arrayFlags) String() string {
+	return fmt.Sprintf("%v", *i)
+}
+
+// Set is an implementation of the flag.Value interface
+func (i *arrayFlags) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
+func main() {
+	var (
+		psbtFile  string
+		cosigners arrayFlags
+		certFile  string
+		keyFile   string
+		caFile    string
+		outFile   string
+	)
+
+	flag.StringVar(&psbtFile, "psbt", "", "Path to the unsigned PSBT")
+	flag.Var(&cosigners, "cosigner", "Cosigner endpoint address (repeatable, one per participant to contact)")
+	flag.StringVar(&certFile, "cert", "", "Coordinator's TLS certificate")
+	flag.StringVar(&keyFile, "key", "", "Coordinator's TLS private key")
+	flag.StringVar(&caFile, "cacert", "", "CA certificate each cosigner's server certificate must chain to")
+	flag.StringVar(&outFile, "out", "", "Where to write the finalized raw transaction hex (stdout if empty)")
+	flag.Parse()
+
+	if psbtFile == "" || len(cosigners) == 0 || certFile == "" || keyFile == "" || caFile == "" {
+		flag.Usage()
+		log.Fatal("-psbt, -cosigner, -cert, -key and -cacert are required")
+	}
+
+	if err := run(psbtFile, cosigners, certFile, keyFile, caFile, outFile); err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+}
+
+func run(psbtFile string, cosigners []string, certFile, keyFile, caFile, outFile string) error {
+	unsigned, err := ourpsbt.ReadFile(psbtFile)
+	if err != nil {
+		return fmt.Errorf("failed to read unsigned PSBT: %w", err)
+	}
+
+	tlsConfig, err := cosignproto.TLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		return err
+	}
+
+	packets := []*psbt.Packet{unsigned}
+	for _, endpoint := range cosigners {
+		packet, err := collectFromCosigner(endpoint, tlsConfig, unsigned)
+		if err != nil {
+			return fmt.Errorf("cosigner %s: %w", endpoint, err)
+		}
+		packets = append(packets, packet)
+		fmt.Println("✅ Received partial signature(s) from", endpoint)
+	}
+
+	merged, err := ourpsbt.Merge(packets...)
+	if err != nil {
+		return fmt.Errorf("failed to merge PSBTs: %w", err)
+	}
+
+	tx, err := ourpsbt.Finalize(merged)
+	if err != nil {
+		return fmt.Errorf("failed to finalize PSBT: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	txHex := hex.EncodeToString(buf.Bytes())
+
+	if outFile == "" {
+		fmt.Printf("✅ Finalized TX (hex): %s\n", txHex)
+		return nil
+	}
+
+	if err := os.WriteFile(outFile, []byte(txHex), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Println("✅ Finalized TX written to:", outFile)
+
+	return nil
+}
+
+// collectFromCosigner connects to a single cosigner's endpoint, sends it
+// the unsigned PSBT and returns its response, which should be the same
+// PSBT with that cosigner's PARTIAL_SIG fields added.
+func collectFromCosigner(endpoint string, tlsConfig *tls.Config, unsigned *psbt.Packet) (*psbt.Packet, error) {
+	conn, err := tls.Dial("tcp", endpoint, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	if err := cosignproto.WritePSBT(conn, unsigned); err != nil {
+		return nil, fmt.Errorf("failed to send PSBT: %w", err)
+	}
+
+	packet, err := cosignproto.ReadPSBT(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return packet, nil
+}
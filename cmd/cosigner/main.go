@@ -0,0 +1,340 @@
+// Command cosigner holds one cosigner's private key(s) and signs
+// PSBTs the coordinator sends it, without ever seeing the other
+// cosigners' keys. It independently re-derives each input's expected
+// scriptPubKey/witness script from its own copy of the descriptor before
+// signing, so a compromised or buggy coordinator can't trick it into
+// signing for the wrong output. It does not yet support tr() descriptors
+// (see expectedIndex/validateInput): key-path MuSig2 and script-path
+// tapscript spends both go through cmd/sign's own out-of-band flow
+// instead of this PSBT-based one.
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/halseth/multisig-check/cosignproto"
+	"github.com/halseth/multisig-check/descriptor"
+	"github.com/halseth/multisig-check/netparams"
+	ourpsbt "github.com/halseth/multisig-check/psbt"
+)
+
+// PrivData mirrors the format privkeys.json is written in by gen; a
+// cosigner's copy holds only the WIF(s) belonging to this participant.
+type PrivData struct {
+	PrivKeyWIF string `json:"derived_priv"`
+	Path       string `json:"path"`
+}
+
+func main() {
+	var (
+		listen   string
+		certFile string
+		keyFile  string
+		caFile   string
+		privFile string
+		descPath string
+		network  string
+		psbtFile string
+		outFile  string
+		dryRun   bool
+	)
+
+	flag.StringVar(&listen, "listen", "", "Address to listen on for coordinator connections (e.g. :9735)")
+	flag.StringVar(&certFile, "cert", "", "This cosigner's TLS certificate")
+	flag.StringVar(&keyFile, "key", "", "This cosigner's TLS private key")
+	flag.StringVar(&caFile, "cacert", "", "CA certificate the coordinator's client certificate must chain to")
+	flag.StringVar(&privFile, "privkeys", "", "Path to this cosigner's own privkeys.json (its keys only)")
+	flag.StringVar(&descPath, "descriptor", "", "Path to the descriptor.txt describing the full multisig quorum")
+	flag.StringVar(&network, "network", "mainnet", "Network: mainnet, testnet, signet or regtest")
+	flag.StringVar(&psbtFile, "psbt", "", "Process a single PSBT file locally instead of listening for a coordinator")
+	flag.StringVar(&outFile, "out", "cosigner-response.psbt", "Where to write the augmented PSBT in -psbt mode")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the sighash(es) this cosigner would sign instead of signing")
+	flag.Parse()
+
+	if privFile == "" || descPath == "" {
+		flag.Usage()
+		log.Fatal("-privkeys and -descriptor are required")
+	}
+	if psbtFile == "" && listen == "" {
+		flag.Usage()
+		log.Fatal("one of -psbt or -listen is required")
+	}
+
+	net, err := netparams.Parse(network)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+
+	desc, err := loadDescriptor(descPath)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+	privKeys, err := loadPrivKeys(privFile)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+
+	if psbtFile != "" {
+		if err := runLocal(psbtFile, outFile, desc, privKeys, net, dryRun); err != nil {
+			log.Fatalf("❌ Error: %v", err)
+		}
+		return
+	}
+
+	if certFile == "" || keyFile == "" || caFile == "" {
+		flag.Usage()
+		log.Fatal("-cert, -key and -cacert are required for -listen")
+	}
+	if err := runServer(listen, certFile, keyFile, caFile, desc, privKeys, net, dryRun); err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+}
+
+func loadDescriptor(path string) (*descriptor.Descriptor, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor file: %w", err)
+	}
+	desc, err := descriptor.Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor: %w", err)
+	}
+	return desc, nil
+}
+
+func loadPrivKeys(path string) ([]*btcutil.WIF, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read privkeys file: %w", err)
+	}
+	var entries []PrivData
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse privkeys JSON: %w", err)
+	}
+	var wifs []*btcutil.WIF
+	for _, e := range entries {
+		wif, err := btcutil.DecodeWIF(e.PrivKeyWIF)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WIF: %w", err)
+		}
+		wifs = append(wifs, wif)
+	}
+	return wifs, nil
+}
+
+// runLocal processes a single PSBT file without any networking, useful
+// for testing a cosigner's setup or running --dry-run against a PSBT a
+// human downloaded from the coordinator out of band.
+func runLocal(psbtFile, outFile string, desc *descriptor.Descriptor, privKeys []*btcutil.WIF, net *chaincfg.Params, dryRun bool) error {
+	packet, err := ourpsbt.ReadFile(psbtFile)
+	if err != nil {
+		return fmt.Errorf("failed to read PSBT: %w", err)
+	}
+
+	signed, err := processPSBT(packet, desc, privKeys, net, dryRun)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+
+	if err := ourpsbt.WriteFile(outFile, packet); err != nil {
+		return fmt.Errorf("failed to write augmented PSBT: %w", err)
+	}
+	fmt.Printf("✅ Added %d partial signature(s)\n", signed)
+	fmt.Println("→ augmented PSBT written to:", outFile)
+	return nil
+}
+
+// runServer listens for coordinator connections and, for each one, reads
+// a PSBT, signs it (or just reports its sighashes, in --dry-run) and
+// writes the result back.
+func runServer(listen, certFile, keyFile, caFile string, desc *descriptor.Descriptor, privKeys []*btcutil.WIF, net *chaincfg.Params, dryRun bool) error {
+	tlsConfig, err := cosignproto.TLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		return err
+	}
+
+	ln, err := tls.Listen("tcp", listen, tlsConfig)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", listen, err)
+	}
+	defer ln.Close()
+
+	fmt.Println("✅ Cosigner listening on", listen)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return fmt.Errorf("accept failed: %w", err)
+		}
+		go func() {
+			defer conn.Close()
+			if err := handleConn(conn, desc, privKeys, net, dryRun); err != nil {
+				log.Printf("❌ Error handling coordinator connection: %v", err)
+			}
+		}()
+	}
+}
+
+func handleConn(conn net.Conn, desc *descriptor.Descriptor, privKeys []*btcutil.WIF, net *chaincfg.Params, dryRun bool) error {
+	packet, err := cosignproto.ReadPSBT(conn)
+	if err != nil {
+		return fmt.Errorf("failed to read PSBT from coordinator: %w", err)
+	}
+
+	if _, err := processPSBT(packet, desc, privKeys, net, dryRun); err != nil {
+		return err
+	}
+
+	return cosignproto.WritePSBT(conn, packet)
+}
+
+// processPSBT validates every input this cosigner's keys are named in
+// against the expected descriptor-derived scriptPubKey/witness script,
+// then either signs it (adding a PARTIAL_SIG) or, in dryRun, prints the
+// sighash it would have signed so the operator can compare it against an
+// independent device before approving the real run.
+func processPSBT(packet *psbt.Packet, desc *descriptor.Descriptor, privKeys []*btcutil.WIF, net *chaincfg.Params, dryRun bool) (int, error) {
+	fetcher, err := ourpsbt.PrevOutFetcher(packet)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build prevout fetcher: %w", err)
+	}
+	sigHashes := txscript.NewTxSigHashes(packet.UnsignedTx, fetcher)
+
+	signed := 0
+	for i, in := range packet.Inputs {
+		if in.WitnessScript == nil || in.WitnessUtxo == nil {
+			continue
+		}
+
+		index, ok, err := expectedIndex(desc, in.Bip32Derivation)
+		if err != nil {
+			return 0, fmt.Errorf("input %d: %w", i, err)
+		}
+		if !ok {
+			// This input isn't for a key we hold; nothing to do.
+			continue
+		}
+
+		if err := validateInput(desc, in, index, net); err != nil {
+			return 0, fmt.Errorf("input %d: %w", i, err)
+		}
+
+		for _, wif := range privKeys {
+			pubKey := wif.PrivKey.PubKey().SerializeCompressed()
+			if !derivesPubkey(in.Bip32Derivation, pubKey) {
+				continue
+			}
+
+			if dryRun {
+				sigHash, err := txscript.CalcWitnessSigHash(
+					in.WitnessScript, sigHashes, txscript.SigHashAll,
+					packet.UnsignedTx, i, in.WitnessUtxo.Value,
+				)
+				if err != nil {
+					return 0, fmt.Errorf("input %d: failed to compute sighash: %w", i, err)
+				}
+				fmt.Printf("[dry-run] input %d, pubkey %x: sighash %s\n", i, pubKey, hex.EncodeToString(sigHash))
+				continue
+			}
+
+			sig, err := txscript.RawTxInWitnessSignature(
+				packet.UnsignedTx, sigHashes, i, in.WitnessUtxo.Value,
+				in.WitnessScript, txscript.SigHashAll, wif.PrivKey,
+			)
+			if err != nil {
+				return 0, fmt.Errorf("failed to sign input %d: %w", i, err)
+			}
+			if err := ourpsbt.AddPartialSig(packet, i, pubKey, sig); err != nil {
+				return 0, fmt.Errorf("failed to add partial sig for input %d: %w", i, err)
+			}
+			signed++
+		}
+	}
+
+	return signed, nil
+}
+
+// expectedIndex finds the derivation index this input was signed at by
+// matching derivs against desc's keys by master fingerprint. ok is false
+// if none of derivs names a key from desc at all (this input isn't ours
+// to validate or sign).
+//
+// desc.Multi is nil for a key-path-only tr() descriptor (see
+// descriptor.Descriptor), which this cosigner does not support signing
+// for over PSBT: MuSig2's nonce exchange has no PSBT field to carry it,
+// so key-path taproot spends go through cmd/sign's out-of-band
+// nonces.json/partial_sigs.json flow instead. Fail closed rather than
+// dereferencing a nil Multi for such a descriptor.
+func expectedIndex(desc *descriptor.Descriptor, derivs []*psbt.Bip32Derivation) (index uint32, ok bool, err error) {
+	if desc.Multi == nil {
+		return 0, false, fmt.Errorf("cosigner does not support key-path-only tr() descriptors")
+	}
+
+	for _, d := range derivs {
+		for _, k := range desc.Multi.Keys {
+			if k.Fingerprint != d.MasterKeyFingerprint {
+				continue
+			}
+			idx, err := k.IndexFromPath(d.Bip32Path)
+			if err != nil {
+				continue
+			}
+			return idx, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// validateInput recomputes the scriptPubKey and witness script the
+// descriptor expects at index and confirms they match what's actually in
+// the PSBT, so a malicious or buggy coordinator can't trick this cosigner
+// into signing for an output it didn't intend to.
+func validateInput(desc *descriptor.Descriptor, in psbt.PInput, index uint32, net *chaincfg.Params) error {
+	if desc.Multi == nil {
+		return fmt.Errorf("cosigner does not support key-path-only tr() descriptors")
+	}
+
+	wantScript, err := desc.DeriveScriptPubKey(index, net)
+	if err != nil {
+		return fmt.Errorf("failed to derive expected scriptPubKey: %w", err)
+	}
+	if !bytes.Equal(wantScript, in.WitnessUtxo.PkScript) {
+		return fmt.Errorf("scriptPubKey does not match descriptor at index %d", index)
+	}
+
+	wantRedeem, err := desc.RedeemScript(index, net)
+	if err != nil {
+		return fmt.Errorf("failed to derive expected witness script: %w", err)
+	}
+	if !bytes.Equal(wantRedeem, in.WitnessScript) {
+		return fmt.Errorf("witness script does not match descriptor at index %d", index)
+	}
+	return nil
+}
+
+// derivesPubkey reports whether pubKey is one of the cosigner keys
+// recorded in the input's BIP32_DERIVATION field.
+func derivesPubkey(derivs []*psbt.Bip32Derivation, pubKey []byte) bool {
+	for _, d := range derivs {
+		if bytes.Equal(d.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,203 +1,187 @@
 package main
 
 import (
-	"bytes"
 	"crypto/sha256"
-	"encoding/base64"
 	"encoding/hex"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
-	"strings"
 
 	"github.com/btcsuite/btcd/btcutil"
-	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+
+	"github.com/halseth/multisig-check/descriptor"
+	"github.com/halseth/multisig-check/netparams"
+	ourpsbt "github.com/halseth/multisig-check/psbt"
 )
 
 var PREVOUT_PREFIX = []byte("txid random prefix")
 
-type XpubDerivation struct {
-	Xpub string `json:"xpub"`
-	Path string `json:"path"`
-}
-
-type JSON struct {
-	Path       string   `json:"path"`
-	Tx         string   `json:"tx"`          // standard (non-url safe) base64
-	VinValues  []uint64 `json:"vin_values"`  // nullable
-	ScriptSigs []string `json:"script_sigs"` // standard (non-url safe) base64s
-}
-
-func parseDerivationPath(path string) ([]uint32, error) {
-	if path == "" {
-		return nil, fmt.Errorf("empty derivation path")
-	}
-	segs := strings.Split(path, "/")
-	var out []uint32
-	for _, s := range segs {
-		var i uint32
-		_, err := fmt.Sscanf(s, "%d", &i)
-		if err != nil {
-			return nil, fmt.Errorf("invalid path segment %q: %w", s, err)
-		}
-		out = append(out, i)
-	}
-	return out, nil
-}
-
 func main() {
 	var (
+		addrType   string
 		addressStr string
 		hexStr     string
-		xpubFile   string
-		threshold  int
+		descPath   string
+		specPath   string
+		index      int
+		outFile    string
+		network    string
 	)
 
-	flag.StringVar(&addressStr, "address", "", "P2WSH Bitcoin address to verify")
+	flag.StringVar(&addrType, "type", "p2wsh", "Output type: p2wsh, p2tr-keypath or p2tr-scriptpath")
+	flag.StringVar(&addressStr, "address", "", "Bitcoin address to verify")
 	flag.StringVar(&hexStr, "hex", "", "32-byte random hex string (to use as seed for prevout)")
-	flag.StringVar(&xpubFile, "xpubs", "", "Path to xpubs.json")
-	flag.IntVar(&threshold, "m", 2, "m: Multisig threshold (e.g. 2-of-3)")
+	flag.StringVar(&descPath, "descriptor", "", "Path to the descriptor.txt produced by gen")
+	flag.StringVar(&specPath, "spec", "", "Path to a spend.json spec (real UTXOs and destination outputs) instead of -address/-hex; p2wsh only")
+	flag.IntVar(&index, "index", 0, "Derivation index to use for any ranged (\"/*\") keys in the descriptor")
+	flag.StringVar(&outFile, "out", "", "Where to write the unsigned spend (default: unsigned.psbt for p2wsh, unsigned.tx for p2tr-*)")
+	flag.StringVar(&network, "network", "mainnet", "Network: mainnet, testnet, signet or regtest")
 	flag.Parse()
 
-	if threshold <= 0 || addressStr == "" || hexStr == "" || xpubFile == "" {
+	if addrType != "p2wsh" && addrType != "p2tr-keypath" && addrType != "p2tr-scriptpath" {
+		log.Fatalf("❌ Error: unknown -type %q", addrType)
+	}
+	if descPath == "" || (specPath == "" && (addressStr == "" || hexStr == "")) {
 		flag.Usage()
 		os.Exit(1)
 	}
+	if specPath != "" && addrType != "p2wsh" {
+		log.Fatal("❌ Error: -spec is only supported for -type p2wsh")
+	}
+	if outFile == "" {
+		if addrType == "p2wsh" {
+			outFile = "unsigned.psbt"
+		} else {
+			outFile = "unsigned.tx"
+		}
+	}
+
+	net, err := netparams.Parse(network)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
 
-	if err := run(addressStr, hexStr, xpubFile, threshold); err != nil {
+	switch {
+	case specPath != "":
+		err = runSpec(descPath, specPath, outFile, net)
+	case addrType == "p2wsh":
+		err = run(addressStr, hexStr, descPath, index, outFile, net)
+	default:
+		err = runTaproot(addressStr, hexStr, descPath, index, outFile, net)
+	}
+	if err != nil {
 		log.Fatalf("❌ Error: %v", err)
 	}
 }
 
-func run(addressStr, hexStr, xpubPath string, threshold int) error {
-	data, err := ioutil.ReadFile(xpubPath)
+func run(addressStr, hexStr, descPath string, index int, outFile string, net *chaincfg.Params) error {
+	data, err := ioutil.ReadFile(descPath)
 	if err != nil {
-		return fmt.Errorf("failed to read xpub file: %w", err)
+		return fmt.Errorf("failed to read descriptor file: %w", err)
 	}
 
-	var xpubs []XpubDerivation
-	if err := json.Unmarshal(data, &xpubs); err != nil {
-		return fmt.Errorf("failed to parse xpubs JSON: %w", err)
+	desc, err := descriptor.Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse descriptor: %w", err)
 	}
 
-	var pubKeys []*btcutil.AddressPubKey
-	for _, x := range xpubs {
-		extKey, err := hdkeychain.NewKeyFromString(x.Xpub)
-		if err != nil {
-			return fmt.Errorf("invalid xpub: %w", err)
-		}
+	pkScript, err := desc.DeriveScriptPubKey(uint32(index), net)
+	if err != nil {
+		return fmt.Errorf("failed to derive scriptPubKey: %w", err)
+	}
 
-		path, err := parseDerivationPath(x.Path)
-		if err != nil {
-			return fmt.Errorf("invalid path %q: %w", x.Path, err)
-		}
+	if err := verifyAddress(pkScript, addressStr, net); err != nil {
+		return err
+	}
+	fmt.Println("✅ Address verification successful.")
 
-		for _, i := range path {
-			extKey, err = extKey.Derive(i)
-			if err != nil {
-				return fmt.Errorf("error deriving child key: %w", err)
-			}
-		}
+	redeemScript, err := desc.RedeemScript(uint32(index), net)
+	if err != nil {
+		return fmt.Errorf("failed to derive redeem script: %w", err)
+	}
 
-		pubKey, err := extKey.ECPubKey()
-		if err != nil {
-			return fmt.Errorf("error getting pubkey: %w", err)
-		}
-		addrPubKey, err := btcutil.NewAddressPubKey(pubKey.SerializeCompressed(), &chaincfg.MainNetParams)
+	var derivations []ourpsbt.Bip32Derivation
+	for _, k := range desc.Multi.Keys {
+		pubKey, err := k.DerivePubKey(uint32(index))
 		if err != nil {
-			return fmt.Errorf("error creating AddressPubKey: %w", err)
+			return fmt.Errorf("failed to derive pubkey: %w", err)
 		}
-		pubKeys = append(pubKeys, addrPubKey)
+		derivations = append(derivations, ourpsbt.Bip32Derivation{
+			MasterFingerprint: k.Fingerprint,
+			Pubkey:            pubKey,
+			Path:              k.FullPath(uint32(index)),
+		})
 	}
 
-	// Build redeem script and verify address
-	redeemScript, err := txscript.MultiSigScript(pubKeys, threshold)
+	outpoint, err := dummyOutpoint(hexStr)
 	if err != nil {
-		return fmt.Errorf("failed to create multisig script: %w", err)
+		return err
 	}
-	redeemHex := hex.EncodeToString(redeemScript)
 
-	witnessProg := sha256.Sum256(redeemScript)
-	addr, err := btcutil.NewAddressWitnessScriptHash(witnessProg[:], &chaincfg.MainNetParams)
-	if err != nil {
-		return fmt.Errorf("failed to derive P2WSH address: %w", err)
+	input := ourpsbt.InputSpec{
+		OutPoint:     *outpoint,
+		Amount:       1000,
+		PkScript:     pkScript,
+		RedeemScript: redeemScript,
+		Derivations:  derivations,
 	}
-
-	if addr.EncodeAddress() != addressStr {
-		return fmt.Errorf("address mismatch: derived %s != expected %s", addr.EncodeAddress(), addressStr)
+	output := ourpsbt.OutputSpec{
+		PkScript: pkScript,
+		Amount:   dummyOutputAmount,
 	}
-	fmt.Println("✅ Address verification successful.")
 
-	// Compute SHA256 of the input hex string to simulate txid
-	// To ensure real transaction data cannot be inserted here, we prepend the message with a fixed string.
-	rawBytes, err := hex.DecodeString(hexStr)
+	packet, err := ourpsbt.New([]ourpsbt.InputSpec{input}, []ourpsbt.OutputSpec{output})
 	if err != nil {
-		return fmt.Errorf("invalid hex string: %w", err)
+		return fmt.Errorf("failed to build unsigned PSBT: %w", err)
 	}
 
-	b := PREVOUT_PREFIX[:]
-	b = append(b, rawBytes...)
-
-	h := sha256.Sum256(b)
-	txid := chainhash.Hash(h)
+	if err := ourpsbt.WriteFile(outFile, packet); err != nil {
+		return fmt.Errorf("failed to write PSBT: %w", err)
+	}
 
-	outpoint := wire.NewOutPoint(&txid, 0)
+	fmt.Printf("Redeem Script (hex): %s\n", hex.EncodeToString(redeemScript))
+	fmt.Println("→ unsigned PSBT written to:", outFile)
 
-	tx := wire.NewMsgTx(wire.TxVersion)
-	txIn := wire.NewTxIn(outpoint, nil, nil)
-	tx.AddTxIn(txIn)
+	return nil
+}
 
-	pkScript, err := txscript.PayToAddrScript(addr)
+// verifyAddress confirms that addressStr decodes, on net, to the same
+// scriptPubKey the descriptor derived.
+func verifyAddress(pkScript []byte, addressStr string, net *chaincfg.Params) error {
+	addr, err := btcutil.DecodeAddress(addressStr, net)
 	if err != nil {
-		return fmt.Errorf("failed to create pkScript: %w", err)
+		return fmt.Errorf("failed to decode address: %w", err)
 	}
-	txOut := wire.NewTxOut(1000, pkScript) // dummy amount
-	tx.AddTxOut(txOut)
-
-	var buf bytes.Buffer
-	if err := tx.Serialize(&buf); err != nil {
-		return fmt.Errorf("failed to serialize transaction: %w", err)
+	wantScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return fmt.Errorf("failed to build scriptPubKey for -address: %w", err)
 	}
-
-	fmt.Printf("Unsigned TX (hex): %x\n", buf.Bytes())
-	fmt.Printf("Redeem Script (hex): %s\n", redeemHex)
-
-	for i, x := range xpubs {
-		jsonBytes := createJson(x.Path, buf.Bytes(), redeemScript)
-
-		jsonName := fmt.Sprintf("unsigned-tx%d.json", i)
-		if err := os.WriteFile(jsonName, jsonBytes, 0644); err != nil {
-			return fmt.Errorf("failed to write unsigned-tx.json: %w",
-				err)
-		}
-
-		fmt.Println("→ tx written to :", jsonName)
+	if hex.EncodeToString(pkScript) != hex.EncodeToString(wantScript) {
+		return fmt.Errorf("address mismatch: descriptor derives a different scriptPubKey than %s", addressStr)
 	}
-
 	return nil
 }
 
-func createJson(path string, txBytes, redeemScript []byte) []byte {
-	b64 := base64.StdEncoding
-
-	scriptSigs := make([]string, 1)
-	scriptSigs[0] = b64.EncodeToString(redeemScript)
-
-	j := JSON{
-		Path:       path,
-		Tx:         b64.EncodeToString(txBytes),
-		VinValues:  []uint64{1000},
-		ScriptSigs: scriptSigs,
+// dummyOutpoint derives a synthetic outpoint from hexStr the same way
+// run() and runTaproot() both need: SHA256 of the input prepended with a
+// fixed prefix, so that a real txid can never be smuggled in here by
+// accident.
+func dummyOutpoint(hexStr string) (*wire.OutPoint, error) {
+	rawBytes, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex string: %w", err)
 	}
 
-	jsonBytes, _ := json.Marshal(j)
+	b := PREVOUT_PREFIX[:]
+	b = append(b, rawBytes...)
 
-	return jsonBytes
+	h := sha256.Sum256(b)
+	txid := chainhash.Hash(h)
+	return wire.NewOutPoint(&txid, 0), nil
 }
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/halseth/multisig-check/coinselect"
+	"github.com/halseth/multisig-check/descriptor"
+	ourpsbt "github.com/halseth/multisig-check/psbt"
+)
+
+// UtxoSpec is one candidate coin to spend from, as listed in -spec's
+// "utxos" array. Index selects which derivation of a ranged descriptor
+// owns this coin (0 for a non-ranged descriptor).
+type UtxoSpec struct {
+	Txid   string `json:"txid"`
+	Vout   uint32 `json:"vout"`
+	Amount int64  `json:"amount"`
+	Index  uint32 `json:"index"`
+}
+
+// DestSpec is one payment to make, as listed in -spec's "outputs" array.
+type DestSpec struct {
+	Address string `json:"address"`
+	Amount  int64  `json:"amount"`
+}
+
+// SpendSpec is the full contents of a -spec file: the UTXOs available to
+// spend, the payments to make, the feerate to target and where any
+// change should go.
+type SpendSpec struct {
+	Utxos         []UtxoSpec `json:"utxos"`
+	Outputs       []DestSpec `json:"outputs"`
+	FeeRateSatVB  int64      `json:"feerate"`
+	ChangeAddress string     `json:"change_address"`
+}
+
+// dustLimit is the smallest change output we'll create; anything below
+// this is added to the fee instead, mirroring typical wallet behavior.
+const dustLimit = 546
+
+// runSpec builds a PSBT spending (a selection of) the UTXOs in specPath
+// to the outputs listed there, deriving each input's redeem script and
+// BIP32 derivation info from desc at that UTXO's index.
+func runSpec(descPath, specPath, outFile string, net *chaincfg.Params) error {
+	descData, err := ioutil.ReadFile(descPath)
+	if err != nil {
+		return fmt.Errorf("failed to read descriptor file: %w", err)
+	}
+	desc, err := descriptor.Parse(string(descData))
+	if err != nil {
+		return fmt.Errorf("failed to parse descriptor: %w", err)
+	}
+
+	specData, err := ioutil.ReadFile(specPath)
+	if err != nil {
+		return fmt.Errorf("failed to read spend spec: %w", err)
+	}
+	var spec SpendSpec
+	if err := json.Unmarshal(specData, &spec); err != nil {
+		return fmt.Errorf("failed to parse spend spec: %w", err)
+	}
+	if spec.FeeRateSatVB <= 0 {
+		return fmt.Errorf("spend spec must set a positive feerate (sat/vbyte)")
+	}
+	if len(spec.Outputs) == 0 {
+		return fmt.Errorf("spend spec must list at least one output")
+	}
+
+	var targetAmount int64
+	for _, o := range spec.Outputs {
+		targetAmount += o.Amount
+	}
+
+	numOutputs := len(spec.Outputs)
+	if spec.ChangeAddress != "" {
+		numOutputs++
+	}
+
+	candidates := make([]coinselect.Utxo, len(spec.Utxos))
+	for i, u := range spec.Utxos {
+		candidates[i] = coinselect.Utxo{
+			Txid: u.Txid, Vout: u.Vout, Amount: u.Amount, Index: u.Index,
+		}
+	}
+
+	if desc.Type != descriptor.WSH || desc.Multi == nil {
+		return fmt.Errorf("-spec only supports a wsh(sortedmulti(...)) descriptor, got %q", desc.Type)
+	}
+
+	params := p2wshSelectionParams(len(desc.Multi.Keys), desc.Multi.Threshold)
+
+	result, err := coinselect.BranchAndBound(candidates, targetAmount, spec.FeeRateSatVB, numOutputs, params)
+	if err != nil {
+		result, err = coinselect.LargestFirst(candidates, targetAmount, spec.FeeRateSatVB, numOutputs, params)
+		if err != nil {
+			return fmt.Errorf("coin selection failed: %w", err)
+		}
+	}
+
+	utxoByOutpoint := make(map[string]UtxoSpec, len(spec.Utxos))
+	for _, u := range spec.Utxos {
+		utxoByOutpoint[fmt.Sprintf("%s:%d", u.Txid, u.Vout)] = u
+	}
+
+	var inputs []ourpsbt.InputSpec
+	for _, sel := range result.Selected {
+		u := utxoByOutpoint[fmt.Sprintf("%s:%d", sel.Txid, sel.Vout)]
+
+		txid, err := chainhash.NewHashFromStr(u.Txid)
+		if err != nil {
+			return fmt.Errorf("invalid txid %q: %w", u.Txid, err)
+		}
+		outpoint := wire.NewOutPoint(txid, u.Vout)
+
+		pkScript, err := desc.DeriveScriptPubKey(u.Index, net)
+		if err != nil {
+			return fmt.Errorf("failed to derive scriptPubKey for index %d: %w", u.Index, err)
+		}
+		redeemScript, err := desc.RedeemScript(u.Index, net)
+		if err != nil {
+			return fmt.Errorf("failed to derive redeem script for index %d: %w", u.Index, err)
+		}
+
+		var derivations []ourpsbt.Bip32Derivation
+		for _, k := range desc.Multi.Keys {
+			pub, err := k.DerivePubKey(u.Index)
+			if err != nil {
+				return fmt.Errorf("failed to derive pubkey for index %d: %w", u.Index, err)
+			}
+			derivations = append(derivations, ourpsbt.Bip32Derivation{
+				MasterFingerprint: k.Fingerprint,
+				Pubkey:            pub,
+				Path:              k.FullPath(u.Index),
+			})
+		}
+
+		inputs = append(inputs, ourpsbt.InputSpec{
+			OutPoint:     *outpoint,
+			Amount:       u.Amount,
+			PkScript:     pkScript,
+			RedeemScript: redeemScript,
+			Derivations:  derivations,
+		})
+	}
+
+	var outputs []ourpsbt.OutputSpec
+	for _, o := range spec.Outputs {
+		addr, err := btcutil.DecodeAddress(o.Address, net)
+		if err != nil {
+			return fmt.Errorf("invalid destination address %q: %w", o.Address, err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return fmt.Errorf("failed to build scriptPubKey for %q: %w", o.Address, err)
+		}
+		outputs = append(outputs, ourpsbt.OutputSpec{PkScript: pkScript, Amount: o.Amount})
+	}
+
+	if result.Change >= dustLimit && spec.ChangeAddress != "" {
+		addr, err := btcutil.DecodeAddress(spec.ChangeAddress, net)
+		if err != nil {
+			return fmt.Errorf("invalid change address %q: %w", spec.ChangeAddress, err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return fmt.Errorf("failed to build scriptPubKey for change: %w", err)
+		}
+		outputs = append(outputs, ourpsbt.OutputSpec{PkScript: pkScript, Amount: result.Change})
+	}
+
+	packet, err := ourpsbt.New(inputs, outputs)
+	if err != nil {
+		return fmt.Errorf("failed to build unsigned PSBT: %w", err)
+	}
+	if err := ourpsbt.WriteFile(outFile, packet); err != nil {
+		return fmt.Errorf("failed to write PSBT: %w", err)
+	}
+
+	fmt.Printf("✅ Selected %d of %d UTXOs, change %d sats\n", len(result.Selected), len(spec.Utxos), result.Change)
+	fmt.Println("→ unsigned PSBT written to:", outFile)
+
+	return nil
+}
+
+// p2wshSelectionParams estimates the per-input/output vsize of a P2WSH
+// numKeys-key, threshold-of-numKeys multisig spend, for fee estimation
+// during coin selection.
+func p2wshSelectionParams(numKeys, threshold int) coinselect.Params {
+	redeemScriptLen := int64(3 + 34*numKeys) // rough upper bound
+	witnessBytes := int64(1) +
+		int64(threshold)*(1+72) + // empty byte + per-sig push (DER sig up to 72 bytes)
+		(1 + redeemScriptLen)
+	nonWitnessInputBytes := int64(36 + 1 + 4) // outpoint + empty scriptSig len + sequence
+
+	return coinselect.Params{
+		OverheadVBytes: 11, // version + locktime + segwit marker/flag + varints
+		InputVBytes:    nonWitnessInputBytes + witnessBytes/4,
+		OutputVBytes:   43, // amount + P2WSH scriptPubKey
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/halseth/multisig-check/descriptor"
+)
+
+// TestSpecEndToEndSignsWithFullKeyset runs the real -spec workflow
+// (coin selection -> sign -> finalize) for a 2-of-3 p2wsh descriptor,
+// signing with all 3 cosigner keys the way a single-machine signer
+// holding everyone's privkeys.json would. This regresses the bug where
+// cmd/sign collected one partial signature per matching key instead of
+// capping at the multisig threshold, leaving finalize unable to
+// finalize a PSBT coin-selected and built by -spec.
+func TestSpecEndToEndSignsWithFullKeyset(t *testing.T) {
+	const (
+		threshold = 2
+		nKeys     = 3
+	)
+	net := &chaincfg.MainNetParams
+
+	var (
+		wifs     []string
+		descKeys []descriptor.Key
+	)
+	for i := 0; i < nKeys; i++ {
+		priv, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key %d: %v", i, err)
+		}
+		wif, err := btcutil.NewWIF(priv, net, true)
+		if err != nil {
+			t.Fatalf("failed to encode WIF: %v", err)
+		}
+		wifs = append(wifs, wif.String())
+		descKeys = append(descKeys, descriptor.Key{
+			XKey: hex.EncodeToString(priv.PubKey().SerializeCompressed()),
+		})
+	}
+
+	desc := &descriptor.Descriptor{
+		Type: descriptor.WSH,
+		Multi: &descriptor.Multi{
+			Threshold: threshold,
+			Sorted:    true,
+			Keys:      descKeys,
+		},
+	}
+	descStr, err := desc.WithChecksum()
+	if err != nil {
+		t.Fatalf("failed to checksum descriptor: %v", err)
+	}
+
+	pkScript, err := desc.DeriveScriptPubKey(0, net)
+	if err != nil {
+		t.Fatalf("failed to derive scriptPubKey: %v", err)
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, net)
+	if err != nil || len(addrs) != 1 {
+		t.Fatalf("failed to extract address from scriptPubKey: %v", err)
+	}
+	multisigAddr := addrs[0].EncodeAddress()
+
+	dir := t.TempDir()
+	descPath := filepath.Join(dir, "descriptor.txt")
+	if err := os.WriteFile(descPath, []byte(descStr), 0644); err != nil {
+		t.Fatalf("failed to write descriptor: %v", err)
+	}
+
+	type privData struct {
+		PrivKeyWIF string `json:"derived_priv"`
+	}
+	privEntries := make([]privData, nKeys)
+	for i, w := range wifs {
+		privEntries[i] = privData{PrivKeyWIF: w}
+	}
+	privJSON, err := json.Marshal(privEntries)
+	if err != nil {
+		t.Fatalf("failed to marshal privkeys: %v", err)
+	}
+	privPath := filepath.Join(dir, "privkeys.json")
+	if err := os.WriteFile(privPath, privJSON, 0644); err != nil {
+		t.Fatalf("failed to write privkeys: %v", err)
+	}
+
+	fundingTxid := chainhash.Hash{}
+	spec := SpendSpec{
+		Utxos: []UtxoSpec{
+			{Txid: fundingTxid.String(), Vout: 0, Amount: 100_000},
+			{Txid: fundingTxid.String(), Vout: 1, Amount: 50_000},
+		},
+		Outputs:       []DestSpec{{Address: multisigAddr, Amount: 120_000}},
+		FeeRateSatVB:  5,
+		ChangeAddress: multisigAddr,
+	}
+	specData, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("failed to marshal spend spec: %v", err)
+	}
+	specPath := filepath.Join(dir, "spend.json")
+	if err := os.WriteFile(specPath, specData, 0644); err != nil {
+		t.Fatalf("failed to write spend spec: %v", err)
+	}
+
+	unsignedPath := filepath.Join(dir, "unsigned.psbt")
+	if err := runSpec(descPath, specPath, unsignedPath, net); err != nil {
+		t.Fatalf("runSpec failed: %v", err)
+	}
+
+	signBin := buildTool(t, dir, "github.com/halseth/multisig-check/cmd/sign")
+	finalizeBin := buildTool(t, dir, "github.com/halseth/multisig-check/cmd/finalize")
+
+	signedPath := filepath.Join(dir, "signed.psbt")
+	runTool(t, signBin,
+		"-psbt", unsignedPath, "-privkeys", privPath, "-out", signedPath,
+	)
+
+	finalTxPath := filepath.Join(dir, "final.tx")
+	runTool(t, finalizeBin, "-psbt", signedPath, "-out", finalTxPath)
+
+	txHex, err := os.ReadFile(finalTxPath)
+	if err != nil {
+		t.Fatalf("failed to read finalized tx: %v", err)
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(txHex)))
+	if err != nil {
+		t.Fatalf("finalized tx is not valid hex: %v", err)
+	}
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		t.Fatalf("finalized tx did not deserialize: %v", err)
+	}
+	if len(tx.TxIn) != 2 {
+		t.Fatalf("expected 2 inputs selected, got %d", len(tx.TxIn))
+	}
+	for i, in := range tx.TxIn {
+		if len(in.Witness) != threshold+2 { // empty + threshold sigs + redeem script
+			t.Fatalf("input %d: expected witness with %d items, got %d", i, threshold+2, len(in.Witness))
+		}
+	}
+}
+
+// buildTool compiles the cmd at importPath into dir and returns the
+// resulting binary's path.
+func buildTool(t *testing.T, dir, importPath string) string {
+	t.Helper()
+	name := filepath.Base(importPath)
+	binPath := filepath.Join(dir, name)
+	cmd := exec.Command("go", "build", "-o", binPath, importPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build %s: %v\n%s", importPath, err, out)
+	}
+	return binPath
+}
+
+// runTool runs the binary at path with args, failing the test on error.
+func runTool(t *testing.T, path string, args ...string) {
+	t.Helper()
+	cmd := exec.Command(path, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%s %v failed: %v\n%s", path, args, err, out)
+	}
+}
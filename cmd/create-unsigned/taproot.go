@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/halseth/multisig-check/descriptor"
+)
+
+// dummyOutputAmount is the single dummy output amount used by both the
+// P2WSH (run) and P2TR (runTaproot) single-input unsigned-spend helpers:
+// a 1000-sat payment back to the same address, just enough to exercise
+// the full signing/verification path without needing a real UTXO.
+const dummyOutputAmount = 1000
+
+// runTaproot builds the unsigned transaction for a single-input P2TR
+// spend (either key-path or script-path) from desc and a dummy prevout
+// derived from hexStr, and writes it as raw transaction hex rather than
+// a PSBT: this tool's taproot signer (cmd/sign's runTaprootKeyPath/
+// runTaprootScriptPath) takes a -tx hex and -address directly, since
+// neither MuSig2's nonce exchange nor a tapscript control block has a
+// PSBT field in btcutil/psbt to round-trip through.
+func runTaproot(addressStr, hexStr, descPath string, index int, outFile string, net *chaincfg.Params) error {
+	data, err := ioutil.ReadFile(descPath)
+	if err != nil {
+		return fmt.Errorf("failed to read descriptor file: %w", err)
+	}
+
+	desc, err := descriptor.Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse descriptor: %w", err)
+	}
+	if desc.Type != descriptor.TR {
+		return fmt.Errorf("descriptor is a %s(), not tr()", desc.Type)
+	}
+
+	pkScript, err := desc.DeriveScriptPubKey(uint32(index), net)
+	if err != nil {
+		return fmt.Errorf("failed to derive scriptPubKey: %w", err)
+	}
+
+	if err := verifyAddress(pkScript, addressStr, net); err != nil {
+		return err
+	}
+	fmt.Println("✅ Address verification successful.")
+
+	outpoint, err := dummyOutpoint(hexStr)
+	if err != nil {
+		return err
+	}
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(outpoint, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(dummyOutputAmount, pkScript))
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return fmt.Errorf("failed to serialize unsigned tx: %w", err)
+	}
+	txHex := hex.EncodeToString(buf.Bytes())
+
+	if err := os.WriteFile(outFile, []byte(txHex), 0644); err != nil {
+		return fmt.Errorf("failed to write unsigned tx: %w", err)
+	}
+
+	fmt.Printf("Unsigned TX (hex): %s\n", txHex)
+	fmt.Println("→ unsigned tx written to:", outFile)
+	fmt.Printf("→ sign with: sign -type %s -tx <hex above> -address %s -amount %d -privkeys privkeys.json\n", descTypeFlag(desc), addressStr, dummyOutputAmount)
+
+	return nil
+}
+
+// descTypeFlag maps a parsed tr() descriptor back to the -type value
+// cmd/sign expects: key-path-only (Multi nil) vs. the single-leaf
+// script-path tree this tool generates.
+func descTypeFlag(desc *descriptor.Descriptor) string {
+	if desc.Multi == nil {
+		return "p2tr-keypath"
+	}
+	return "p2tr-scriptpath"
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+
+	ourpsbt "github.com/halseth/multisig-check/psbt"
+)
+
+type arrayFlags []string
+
+// String is an implementation of the flag.Value interface
+func (i *arrayFlags) String() string {
+	return fmt.Sprintf("%v", *i)
+}
+
+// Set is an implementation of the flag.Value interface
+func (i *arrayFlags) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
+func main() {
+	var (
+		psbtFiles arrayFlags
+		outFile   string
+	)
+
+	flag.Var(&psbtFiles, "psbt", "Partially-signed PSBT (repeatable, one per cosigner)")
+	flag.StringVar(&outFile, "out", "", "Where to write the finalized raw transaction hex (stdout if empty)")
+	flag.Parse()
+
+	if len(psbtFiles) == 0 {
+		flag.Usage()
+		log.Fatal("At least one -psbt is required")
+	}
+
+	if err := run(psbtFiles, outFile); err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+}
+
+func run(psbtFiles []string, outFile string) error {
+	var packets []*psbt.Packet
+	for _, f := range psbtFiles {
+		packet, err := ourpsbt.ReadFile(f)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f, err)
+		}
+		packets = append(packets, packet)
+	}
+
+	merged, err := ourpsbt.Merge(packets...)
+	if err != nil {
+		return fmt.Errorf("failed to merge PSBTs: %w", err)
+	}
+
+	tx, err := ourpsbt.Finalize(merged)
+	if err != nil {
+		return fmt.Errorf("failed to finalize PSBT: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	txHex := hex.EncodeToString(buf.Bytes())
+
+	if outFile == "" {
+		fmt.Printf("✅ Finalized TX (hex): %s\n", txHex)
+		return nil
+	}
+
+	if err := os.WriteFile(outFile, []byte(txHex), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Println("✅ Finalized TX written to:", outFile)
+
+	return nil
+}
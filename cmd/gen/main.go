@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -10,21 +11,22 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/tyler-smith/go-bip39"
-)
 
-type PubOutput struct {
-	Xpub   string `json:"xpub"`
-	Path   string `json:"path"`
-	Pubkey string `json:"pubkey"`
-}
+	"github.com/halseth/multisig-check/descriptor"
+	"github.com/halseth/multisig-check/netparams"
+	"github.com/halseth/multisig-check/taproot"
+)
 
 type PrivOutput struct {
 	Xpriv      string `json:"xpriv"`
@@ -39,6 +41,8 @@ func main() {
 		hexSeed      string
 		mnemonic     string
 		pathTemplate string
+		addrType     string
+		network      string
 	)
 
 	flag.StringVar(&hexSeed, "hex_seed", "", "BIP32 master seed in hex (if not set, a random one will be used)")
@@ -46,14 +50,24 @@ func main() {
 	flag.StringVar(&pathTemplate, "path", "m/84'/0'/0'/0/i", "Derivation path template (use 'i' for key index)")
 	flag.IntVar(&nKeys, "n", 3, "n: Total keys(e.g. 2-of-3)")
 	flag.IntVar(&threshold, "m", 2, "m: Multisig threshold (e.g. 2-of-3)")
+	flag.StringVar(&addrType, "type", "p2wsh", "Output type: p2wsh, p2tr-keypath or p2tr-scriptpath")
+	flag.StringVar(&network, "network", "mainnet", "Network: mainnet, testnet, signet or regtest")
 	flag.Parse()
 
+	if addrType != "p2wsh" && addrType != "p2tr-keypath" && addrType != "p2tr-scriptpath" {
+		log.Fatalf("❌ Error: unknown -type %q", addrType)
+	}
+
+	net, err := netparams.Parse(network)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
+	}
+
 	if hexSeed != "" && mnemonic != "" {
 		log.Fatal("❌ Error: cannot specify both -hex_seed and -mnemonic")
 	}
 
 	var seed []byte
-	var err error
 
 	if mnemonic != "" {
 		seed, err = mnemonicToSeed(mnemonic)
@@ -73,7 +87,7 @@ func main() {
 		}
 	}
 
-	if err := printXpubFromSeed(seed); err != nil {
+	if err := printXpubFromSeed(seed, net); err != nil {
 		log.Fatalf("❌ Error: %v", err)
 	}
 
@@ -82,7 +96,7 @@ func main() {
 		log.Fatal("All flags are required for m-of-n setup")
 	}
 
-	if err := run(seed, threshold, nKeys, pathTemplate); err != nil {
+	if err := run(seed, threshold, nKeys, pathTemplate, addrType, net); err != nil {
 		fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -121,9 +135,9 @@ func parsePath(pathTemplate string, index int) ([]uint32, error) {
 	return []uint32(derivPath), nil
 }
 
-func printXpubFromSeed(seed []byte) error {
+func printXpubFromSeed(seed []byte, net *chaincfg.Params) error {
 	// Create master key from seed
-	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	masterKey, err := hdkeychain.NewMaster(seed, net)
 	if err != nil {
 		return fmt.Errorf("failed to create master key: %w", err)
 	}
@@ -141,55 +155,209 @@ func printXpubFromSeed(seed []byte) error {
 	return nil
 }
 
-func run(seed []byte, nRequired, nKeys int, pathTemplate string) error {
+func run(seed []byte, nRequired, nKeys int, pathTemplate, addrType string, net *chaincfg.Params) error {
 
-	var pubs []PubOutput
-	var privs []PrivOutput
-	var addrPubKeys []*btcutil.AddressPubKey
+	master, err := hdkeychain.NewMaster(seed, net)
+	if err != nil {
+		return fmt.Errorf("failed to create master key: %w", err)
+	}
+	fingerprint, err := descriptor.Fingerprint(master)
+	if err != nil {
+		return fmt.Errorf("failed to compute master fingerprint: %w", err)
+	}
+
+	var (
+		privs       []PrivOutput
+		addrPubKeys []*btcutil.AddressPubKey
+		pubKeys     []*btcec.PublicKey
+		descKeys    []descriptor.Key
+	)
 
 	for i := 0; i < nKeys; i++ {
 		path, err := parsePath(pathTemplate, i)
 		if err != nil {
 			return fmt.Errorf("failed to parse path for key %d: %w", i, err)
 		}
-		pub, priv, addrPubKey, err := deriveKeyData(seed, path)
+		priv, addrPubKey, err := deriveKeyData(seed, path, net)
 		if err != nil {
 			return fmt.Errorf("failed to derive key: %w", err)
 		}
 
-		pubs = append(pubs, pub)
 		privs = append(privs, priv)
 		addrPubKeys = append(addrPubKeys, addrPubKey)
+		pubKeys = append(pubKeys, addrPubKey.PubKey())
+
+		descKey, err := accountKey(master, fingerprint, path)
+		if err != nil {
+			return fmt.Errorf("failed to build key origin for key %d: %w", i, err)
+		}
+		descKeys = append(descKeys, descKey)
+	}
+
+	// Write private key data
+	if err := writeJSON("privkeys.json", privs); err != nil {
+		return fmt.Errorf("failed to write privkeys.json: %w", err)
 	}
 
-	// Create redeem script and address
-	redeemScript, err := txscript.MultiSigScript(addrPubKeys, nRequired)
+	fmt.Println("✅ Generated multisig data")
+	fmt.Println("→ Private keys saved to: privkeys.json")
+
+	switch addrType {
+	case "p2wsh":
+		return runP2WSH(addrPubKeys, nRequired, descKeys, net)
+	case "p2tr-keypath":
+		return runP2TRKeyPath(pubKeys, net)
+	case "p2tr-scriptpath":
+		return runP2TRScriptPath(pubKeys, nRequired, descKeys, net)
+	default:
+		return fmt.Errorf("unknown -type %q", addrType)
+	}
+}
+
+// accountKey splits path into its hardened "account" prefix (the part
+// that needs the private master key to derive) and the remaining
+// non-hardened suffix, and returns a descriptor.Key whose XKey is the
+// account-level xpub, safe to hand to a watch-only consumer.
+func accountKey(master *hdkeychain.ExtendedKey, fingerprint uint32, path []uint32) (descriptor.Key, error) {
+	prefix, suffix := descriptor.SplitHardenedPrefix(path)
+
+	account := master
+	for _, i := range prefix {
+		var err error
+		account, err = account.Derive(i)
+		if err != nil {
+			return descriptor.Key{}, err
+		}
+	}
+	accountXpub, err := account.Neuter()
+	if err != nil {
+		return descriptor.Key{}, err
+	}
+
+	return descriptor.Key{
+		Fingerprint: fingerprint,
+		OriginPath:  prefix,
+		XKey:        accountXpub.String(),
+		ChildPath:   suffix,
+	}, nil
+}
+
+func runP2WSH(addrPubKeys []*btcutil.AddressPubKey, nRequired int, descKeys []descriptor.Key, net *chaincfg.Params) error {
+	// descriptor.txt below is written as sortedmulti(), so the redeem
+	// script must be built from keys in the same lexicographic-by-
+	// compressed-pubkey order that DeriveAddressPubKeys re-sorts to,
+	// or the address we print here won't match what Core/Sparrow/HWI
+	// derive from the descriptor.
+	sorted := make([]*btcutil.AddressPubKey, len(addrPubKeys))
+	copy(sorted, addrPubKeys)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].ScriptAddress(), sorted[j].ScriptAddress()) < 0
+	})
+
+	redeemScript, err := txscript.MultiSigScript(sorted, nRequired)
 	if err != nil {
 		return fmt.Errorf("failed to create redeem script: %w", err)
 	}
 	witnessProg := sha256.Sum256(redeemScript)
-	addr, err := btcutil.NewAddressWitnessScriptHash(witnessProg[:], &chaincfg.MainNetParams)
+	addr, err := btcutil.NewAddressWitnessScriptHash(witnessProg[:], net)
 	if err != nil {
 		return fmt.Errorf("failed to create P2WSH address: %w", err)
 	}
 
-	// Write public key data
-	if err := writeJSON("xpubs.json", pubs); err != nil {
-		return fmt.Errorf("failed to write xpubs.json: %w", err)
+	desc := &descriptor.Descriptor{
+		Type: descriptor.WSH,
+		Multi: &descriptor.Multi{
+			Threshold: nRequired,
+			Sorted:    true,
+			Keys:      descKeys,
+		},
 	}
-
-	// Write private key data
-	if err := writeJSON("privkeys.json", privs); err != nil {
-		return fmt.Errorf("failed to write privkeys.json: %w", err)
+	descStr, err := desc.WithChecksum()
+	if err != nil {
+		return fmt.Errorf("failed to checksum descriptor: %w", err)
+	}
+	if err := os.WriteFile("descriptor.txt", []byte(descStr), 0644); err != nil {
+		return fmt.Errorf("failed to write descriptor.txt: %w", err)
 	}
 
-	// Output summary
-	fmt.Println("✅ Generated multisig data")
 	fmt.Println("P2WSH Address:", addr.EncodeAddress())
 	fmt.Println("Redeem Script (hex):", hex.EncodeToString(redeemScript))
 	fmt.Println("Redeem Script (base64):", base64.StdEncoding.EncodeToString(redeemScript))
-	fmt.Println("→ Public metadata saved to: xpubs.json")
-	fmt.Println("→ Private keys saved to:    privkeys.json")
+	fmt.Println("Output descriptor:", descStr)
+	fmt.Println("→ Output descriptor saved to: descriptor.txt")
+
+	return nil
+}
+
+func runP2TRKeyPath(pubKeys []*btcec.PublicKey, net *chaincfg.Params) error {
+	addr, aggKey, err := taproot.KeyPathAddress(pubKeys, net)
+	if err != nil {
+		return fmt.Errorf("failed to derive key-path P2TR address: %w", err)
+	}
+
+	// The descriptor's internal key is the pre-tweak MuSig2 aggregate
+	// (DeriveScriptPubKey applies the same BIP-86 tweak again to get
+	// from there to the address), not the final, already-tweaked key.
+	desc := &descriptor.Descriptor{
+		Type: descriptor.TR,
+		InternalKey: &descriptor.Key{
+			XKey: hex.EncodeToString(schnorr.SerializePubKey(aggKey.PreTweakedKey)),
+		},
+	}
+	descStr, err := desc.WithChecksum()
+	if err != nil {
+		return fmt.Errorf("failed to checksum descriptor: %w", err)
+	}
+	if err := os.WriteFile("descriptor.txt", []byte(descStr), 0644); err != nil {
+		return fmt.Errorf("failed to write descriptor.txt: %w", err)
+	}
+
+	fmt.Println("P2TR (key-path) Address:", addr.EncodeAddress())
+	fmt.Println("Output descriptor:", descStr)
+	fmt.Println("→ Output descriptor saved to: descriptor.txt")
+
+	return nil
+}
+
+func runP2TRScriptPath(pubKeys []*btcec.PublicKey, nRequired int, descKeys []descriptor.Key, net *chaincfg.Params) error {
+	addr, _, _, err := taproot.ScriptPathAddress(pubKeys, nRequired, net)
+	if err != nil {
+		return fmt.Errorf("failed to derive script-path P2TR address: %w", err)
+	}
+
+	leaf, err := taproot.MultisigLeafScript(pubKeys, nRequired)
+	if err != nil {
+		return fmt.Errorf("failed to build multisig leaf script: %w", err)
+	}
+
+	desc := &descriptor.Descriptor{
+		Type: descriptor.TR,
+		InternalKey: &descriptor.Key{
+			XKey: hex.EncodeToString(schnorr.SerializePubKey(taproot.NUMSInternalKey)),
+		},
+		Multi: &descriptor.Multi{
+			// Sorted: false (multi_a, not sortedmulti_a): the leaf
+			// script/address above are built from pubKeys in the order
+			// the caller passed them in, not lexicographic order. A
+			// sortedmulti_a() descriptor would re-sort at derive time
+			// and disagree with the address this tool just printed.
+			Threshold: nRequired,
+			Sorted:    false,
+			Keys:      descKeys,
+		},
+	}
+	descStr, err := desc.WithChecksum()
+	if err != nil {
+		return fmt.Errorf("failed to checksum descriptor: %w", err)
+	}
+	if err := os.WriteFile("descriptor.txt", []byte(descStr), 0644); err != nil {
+		return fmt.Errorf("failed to write descriptor.txt: %w", err)
+	}
+
+	fmt.Println("P2TR (script-path) Address:", addr.EncodeAddress())
+	fmt.Println("Tapscript leaf (hex):", hex.EncodeToString(leaf.Script))
+	fmt.Println("Output descriptor:", descStr)
+	fmt.Println("→ Output descriptor saved to: descriptor.txt")
 
 	return nil
 }
@@ -202,10 +370,10 @@ func randomSeed() ([]byte, error) {
 	return seed, nil
 }
 
-func deriveKeyData(seed []byte, path []uint32) (PubOutput, PrivOutput, *btcutil.AddressPubKey, error) {
-	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+func deriveKeyData(seed []byte, path []uint32, net *chaincfg.Params) (PrivOutput, *btcutil.AddressPubKey, error) {
+	master, err := hdkeychain.NewMaster(seed, net)
 	if err != nil {
-		return PubOutput{}, PrivOutput{}, nil, err
+		return PrivOutput{}, nil, err
 	}
 
 	// Derive child key
@@ -213,7 +381,7 @@ func deriveKeyData(seed []byte, path []uint32) (PubOutput, PrivOutput, *btcutil.
 	for _, i := range path {
 		current, err = current.Derive(i)
 		if err != nil {
-			return PubOutput{}, PrivOutput{}, nil, err
+			return PrivOutput{}, nil, err
 		}
 	}
 
@@ -222,42 +390,32 @@ func deriveKeyData(seed []byte, path []uint32) (PubOutput, PrivOutput, *btcutil.
 
 	pubKey, err := current.ECPubKey()
 	if err != nil {
-		return PubOutput{}, PrivOutput{}, nil, err
+		return PrivOutput{}, nil, err
 	}
 
 	// Get compressed pubkey (33 bytes with 02/03 prefix)
 	compressedPubkey := pubKey.SerializeCompressed()
 
-	addrPubKey, err := btcutil.NewAddressPubKey(compressedPubkey, &chaincfg.MainNetParams)
+	addrPubKey, err := btcutil.NewAddressPubKey(compressedPubkey, net)
 	if err != nil {
-		return PubOutput{}, PrivOutput{}, nil, err
+		return PrivOutput{}, nil, err
 	}
 
 	privKey, err := current.ECPrivKey()
 	if err != nil {
-		return PubOutput{}, PrivOutput{}, nil, err
+		return PrivOutput{}, nil, err
 	}
-	privWIF, err := btcutil.NewWIF(privKey, &chaincfg.MainNetParams, true)
+	privWIF, err := btcutil.NewWIF(privKey, net, true)
 	if err != nil {
-		return PubOutput{}, PrivOutput{}, nil, err
+		return PrivOutput{}, nil, err
 	}
 
-	xpub, err := master.Neuter()
-	if err != nil {
-		return PubOutput{}, PrivOutput{}, nil, err
-	}
-
-	pub := PubOutput{
-		Xpub:   xpub.String(),
-		Path:   pathStr,
-		Pubkey: hex.EncodeToString(compressedPubkey),
-	}
 	priv := PrivOutput{
 		Xpriv:      master.String(),
 		PrivKeyWIF: privWIF.String(),
 		Path:       pathStr,
 	}
-	return pub, priv, addrPubKey, nil
+	return priv, addrPubKey, nil
 }
 
 func writeJSON(filename string, v interface{}) error {
@@ -2,18 +2,18 @@ package main
 
 import (
 	"bytes"
-	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 
 	"github.com/btcsuite/btcd/btcutil"
-	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/txscript"
-	"github.com/btcsuite/btcd/wire"
+
+	"github.com/halseth/multisig-check/netparams"
+	ourpsbt "github.com/halseth/multisig-check/psbt"
 )
 
 type PrivData struct {
@@ -21,177 +21,176 @@ type PrivData struct {
 	Path       string `json:"path"`
 }
 
-type JSON struct {
-	Path       string   `json:"path"`
-	Tx         string   `json:"tx"`          // standard (non-url safe) base64
-	VinValues  []int64  `json:"vin_values"`  // nullable
-	ScriptSigs []string `json:"script_sigs"` // standard (non-url safe) base64s
-}
-
-func getScriptPubKeyFromAddress(address string) ([]byte, error) {
-	addr, err := btcutil.DecodeAddress(address, &chaincfg.MainNetParams)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode address: %w", err)
-	}
-	if _, ok := addr.(*btcutil.AddressWitnessScriptHash); !ok {
-		return nil, errors.New("address must be a P2WSH address")
-	}
-	script, err := txscript.PayToAddrScript(addr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create scriptPubKey: %w", err)
-	}
-	return script, nil
-}
-
-type arrayFlags []string
-
-// String is an implementation of the flag.Value interface
-func (i *arrayFlags) String() string {
-	return fmt.Sprintf("%v", *i)
-}
-
-// Set is an implementation of the flag.Value interface
-func (i *arrayFlags) Set(value string) error {
-	*i = append(*i, value)
-	return nil
-}
-
 func main() {
 	var (
-		address  string
-		txFiles  arrayFlags
+		addrType string
+		psbtFile string
 		privFile string
+		outFile  string
+		txHex    string
+		address  string
+		amount   int64
+		thresh   int
+		network  string
 	)
 
-	flag.StringVar(&address, "address", "", "P2WSH address being spent from")
-	flag.Var(&txFiles, "tx", "Unsigned transaction json")
+	flag.StringVar(&addrType, "type", "p2wsh", "Output type: p2wsh, p2tr-keypath or p2tr-scriptpath")
+	flag.StringVar(&psbtFile, "psbt", "", "Path to the unsigned PSBT (p2wsh only)")
 	flag.StringVar(&privFile, "privkeys", "", "Path to privkeys.json")
+	flag.StringVar(&outFile, "out", "", "Where to write the signed output (default depends on -type)")
+	flag.StringVar(&txHex, "tx", "", "Unsigned transaction hex (p2tr-* only)")
+	flag.StringVar(&address, "address", "", "Taproot address being spent from (p2tr-* only)")
+	flag.Int64Var(&amount, "amount", 1000, "Amount of the input being spent, in sats (p2tr-* only)")
+	flag.IntVar(&thresh, "m", 2, "m: Multisig threshold (p2tr-scriptpath only)")
+	flag.StringVar(&network, "network", "mainnet", "Network: mainnet, testnet, signet or regtest (p2tr-* only)")
 	flag.Parse()
 
-	if address == "" || len(txFiles) == 0 || privFile == "" {
+	if privFile == "" {
 		flag.Usage()
-		log.Fatal("All flags are required")
+		log.Fatal("-privkeys is required")
 	}
 
-	var txJson []JSON
-	for _, f := range txFiles {
-		data, err := ioutil.ReadFile(f)
-		if err != nil {
-			log.Fatalf("Failed to read json file: %v", err)
-		}
-
-		var tx JSON
-		if err := json.Unmarshal(data, &tx); err != nil {
-			log.Fatalf("Failed to parse privkeys JSON: %v", err)
-		}
-
-		txJson = append(txJson, tx)
+	net, err := netparams.Parse(network)
+	if err != nil {
+		log.Fatalf("❌ Error: %v", err)
 	}
-
-	var rawTxHex string
-	var redeemHex string
-	var amountSats int64
-	for _, j := range txJson {
-		raw := j.Tx
-		redeem := j.ScriptSigs
-		amt := j.VinValues
-		if rawTxHex != "" && rawTxHex != raw {
-			log.Fatalf("tx hex doesnt match")
+	switch addrType {
+	case "p2wsh":
+		if outFile == "" {
+			outFile = "signed-tx.psbt"
 		}
-		if len(redeem) != 1 {
-			log.Fatalf("no sript sigs")
+		if psbtFile == "" {
+			flag.Usage()
+			log.Fatal("-psbt is required for -type p2wsh")
 		}
-		if redeemHex != "" && redeemHex != redeem[0] {
-			log.Fatalf("sript sigs dont match")
+		err = run(psbtFile, privFile, outFile)
+	case "p2tr-keypath":
+		if outFile == "" {
+			outFile = "signed-tx.hex"
 		}
-		if len(amt) != 1 {
-			log.Fatalf("no vin")
+		if txHex == "" || address == "" {
+			flag.Usage()
+			log.Fatal("-tx and -address are required for -type p2tr-keypath")
 		}
-		if amountSats != 0 && amountSats != amt[0] {
-			log.Fatalf("amounts dont match")
+		err = runTaprootKeyPath(txHex, address, privFile, outFile, amount, net)
+	case "p2tr-scriptpath":
+		if outFile == "" {
+			outFile = "signed-tx.hex"
 		}
-
-		rawTxHex = raw
-		redeemHex = redeem[0]
-		amountSats = amt[0]
-	}
-
-	b64 := base64.StdEncoding
-	rawTx, err := b64.DecodeString(rawTxHex)
-	if err != nil {
-		log.Fatalf("Invalid tx hex: %v", err)
-	}
-
-	tx := wire.NewMsgTx(wire.TxVersion)
-	if err := tx.Deserialize(bytes.NewReader(rawTx)); err != nil {
-		log.Fatalf("Failed to deserialize tx: %v", err)
+		if txHex == "" || address == "" {
+			flag.Usage()
+			log.Fatal("-tx and -address are required for -type p2tr-scriptpath")
+		}
+		err = runTaprootScriptPath(txHex, address, privFile, outFile, amount, thresh, net)
+	default:
+		log.Fatalf("❌ Error: unknown -type %q", addrType)
 	}
 
-	redeemScript, err := b64.DecodeString(redeemHex)
 	if err != nil {
-		log.Fatalf("Invalid redeem script: %v", err)
+		log.Fatalf("❌ Error: %v", err)
 	}
+}
 
-	scriptPubKey, err := getScriptPubKeyFromAddress(address)
+func run(psbtFile, privFile, outFile string) error {
+	packet, err := ourpsbt.ReadFile(psbtFile)
 	if err != nil {
-		log.Fatalf("❌ ScriptPubKey error: %v", err)
+		return fmt.Errorf("failed to read PSBT: %w", err)
 	}
 
-	// Load private keys
 	data, err := ioutil.ReadFile(privFile)
 	if err != nil {
-		log.Fatalf("Failed to read privkeys file: %v", err)
+		return fmt.Errorf("failed to read privkeys file: %w", err)
 	}
 	var privEntries []PrivData
 	if err := json.Unmarshal(data, &privEntries); err != nil {
-		log.Fatalf("Failed to parse privkeys JSON: %v", err)
+		return fmt.Errorf("failed to parse privkeys JSON: %w", err)
 	}
 
-	var sigs [][]byte
-	for _, j := range txJson {
-		for _, p := range privEntries {
-			if p.Path != j.Path {
-				continue
-			}
+	var wifs []*btcutil.WIF
+	for _, p := range privEntries {
+		wif, err := btcutil.DecodeWIF(p.PrivKeyWIF)
+		if err != nil {
+			return fmt.Errorf("invalid WIF: %w", err)
+		}
+		wifs = append(wifs, wif)
+	}
 
-			wif, err := btcutil.DecodeWIF(p.PrivKeyWIF)
-			if err != nil {
-				log.Fatalf("Invalid WIF: %v", err)
-			}
+	fetcher, err := ourpsbt.PrevOutFetcher(packet)
+	if err != nil {
+		return fmt.Errorf("failed to build prevout fetcher: %w", err)
+	}
+	sigHashes := txscript.NewTxSigHashes(packet.UnsignedTx, fetcher)
+
+	signed := 0
+	for i, in := range packet.Inputs {
+		if in.WitnessScript == nil || in.WitnessUtxo == nil {
+			continue
+		}
 
-			prevOutFetcher := txscript.NewCannedPrevOutputFetcher(
-				scriptPubKey, amountSats,
+		_, threshold, err := txscript.CalcMultiSigStats(in.WitnessScript)
+		if err != nil {
+			return fmt.Errorf("input %d: failed to read multisig threshold from witness script: %w", i, err)
+		}
+
+		var matched []*btcutil.WIF
+		for _, wif := range wifs {
+			if derivesPubkey(in.Bip32Derivation, wif.PrivKey.PubKey().SerializeCompressed()) {
+				matched = append(matched, wif)
+			}
+		}
+		if len(matched) < threshold {
+			return fmt.Errorf(
+				"input %d: only %d of the required %d signing keys are present in -privkeys",
+				i, len(matched), threshold,
 			)
+		}
 
-			sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+		// Sign with exactly `threshold` of the matched keys: the
+		// redeem script only has room for `threshold` signatures, and
+		// -privkeys may legitimately hold every cosigner's key for a
+		// single-machine m-of-n setup, not just the m meant to sign.
+		for _, wif := range matched[:threshold] {
+			pubKey := wif.PrivKey.PubKey().SerializeCompressed()
 
 			sig, err := txscript.RawTxInWitnessSignature(
-				tx, sigHashes, 0, amountSats,
-				redeemScript, txscript.SigHashAll, wif.PrivKey,
+				packet.UnsignedTx, sigHashes, i, in.WitnessUtxo.Value,
+				in.WitnessScript, txscript.SigHashAll, wif.PrivKey,
 			)
 			if err != nil {
-				log.Fatalf("Signing failed: %v", err)
+				return fmt.Errorf("failed to sign input %d: %w", i, err)
+			}
+
+			if err := ourpsbt.AddPartialSig(packet, i, pubKey, sig); err != nil {
+				return fmt.Errorf("failed to add partial sig: %w", err)
 			}
-			sigs = append(sigs, sig)
-			break
+			signed++
 		}
 	}
 
-	// Build multisig witness stack: empty + sig1 + sig2 + redeem script
-	witness := wire.TxWitness{[]byte{}}
-	for i := 0; i < len(txJson); i++ {
-		sig := sigs[i]
-		witness = append(witness, sig)
+	if signed == 0 {
+		return fmt.Errorf("no matching keys found for any input")
+	}
 
+	if err := ourpsbt.WriteFile(outFile, packet); err != nil {
+		return fmt.Errorf("failed to write signed PSBT: %w", err)
 	}
-	witness = append(witness, redeemScript)
 
-	tx.TxIn[0].Witness = witness
+	fmt.Printf("✅ Added %d partial signature(s)\n", signed)
+	fmt.Println("→ partially-signed PSBT written to:", outFile)
 
-	var buf bytes.Buffer
-	if err := tx.Serialize(&buf); err != nil {
-		log.Fatalf("Failed to serialize tx: %v", err)
-	}
+	return nil
+}
 
-	fmt.Printf("✅ Signed TX (hex): %x\n", buf.Bytes())
+// derivesPubkey reports whether pubKey is one of the cosigner keys
+// recorded in the input's BIP32_DERIVATION field. Matching on the
+// derived pubkey itself (rather than on the derivation path string)
+// means the signer never needs to trust that paths line up across
+// files; the PSBT already proves which key signs which input.
+func derivesPubkey(derivs []*psbt.Bip32Derivation, pubKey []byte) bool {
+	for _, d := range derivs {
+		if bytes.Equal(d.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
 }
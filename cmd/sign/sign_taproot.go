@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/halseth/multisig-check/taproot"
+)
+
+// NonceEntry is one cosigner's round-1 MuSig2 public nonce, as shared in
+// nonces.json so every other cosigner can register it before round 2.
+type NonceEntry struct {
+	Pubkey string `json:"pubkey"`
+	Nonce  string `json:"nonce"`
+}
+
+// PartialSigEntry is one cosigner's round-2 MuSig2 partial signature, as
+// shared in partial_sigs.json before the final aggregate signature can
+// be computed.
+type PartialSigEntry struct {
+	Pubkey string `json:"pubkey"`
+	Sig    string `json:"partial_sig"`
+}
+
+func loadPrivKeys(privFile string) ([]*btcec.PrivateKey, error) {
+	data, err := ioutil.ReadFile(privFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read privkeys file: %w", err)
+	}
+	var entries []PrivData
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse privkeys JSON: %w", err)
+	}
+
+	var privKeys []*btcec.PrivateKey
+	for _, e := range entries {
+		wif, err := btcutil.DecodeWIF(e.PrivKeyWIF)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WIF: %w", err)
+		}
+		privKeys = append(privKeys, wif.PrivKey)
+	}
+	return privKeys, nil
+}
+
+func decodeTxHex(txHex string) (*wire.MsgTx, error) {
+	raw, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tx hex: %w", err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("failed to deserialize tx: %w", err)
+	}
+	return tx, nil
+}
+
+func taprootPrevOutFetcher(address string, amount int64, net *chaincfg.Params) (txscript.PrevOutputFetcher, error) {
+	addr, err := btcutil.DecodeAddress(address, net)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode address: %w", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pkScript: %w", err)
+	}
+	return txscript.NewCannedPrevOutputFetcher(pkScript, amount), nil
+}
+
+func encodePartialSig(sig *musig2.PartialSignature) (string, error) {
+	var buf bytes.Buffer
+	if err := sig.Encode(&buf); err != nil {
+		return "", fmt.Errorf("failed to encode partial signature: %w", err)
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+func decodePartialSig(s string) (*musig2.PartialSignature, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid partial signature hex: %w", err)
+	}
+	sig := &musig2.PartialSignature{}
+	if err := sig.Decode(bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("failed to decode partial signature: %w", err)
+	}
+	return sig, nil
+}
+
+// runTaprootKeyPath drives every cosigner's privkey through the
+// two-round MuSig2 protocol in a single process (the same single-machine
+// trust model the other signers here use; splitting the rounds across
+// real cosigner machines is the coordinator's job), writing the
+// intermediate nonces.json and partial_sigs.json artifacts so the
+// exchange can be inspected or replayed over a real transport.
+func runTaprootKeyPath(txHex, address, privFile, outFile string, amount int64, net *chaincfg.Params) error {
+	tx, err := decodeTxHex(txHex)
+	if err != nil {
+		return err
+	}
+
+	privKeys, err := loadPrivKeys(privFile)
+	if err != nil {
+		return err
+	}
+
+	var pubKeys []*btcec.PublicKey
+	for _, p := range privKeys {
+		pubKeys = append(pubKeys, p.PubKey())
+	}
+
+	fetcher, err := taprootPrevOutFetcher(address, amount, net)
+	if err != nil {
+		return err
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+	msg, err := txscript.CalcTaprootSignatureHash(
+		sigHashes, txscript.SigHashDefault, tx, 0, fetcher,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to compute taproot sighash: %w", err)
+	}
+	var msgHash [32]byte
+	copy(msgHash[:], msg)
+
+	// Round 1: every signer generates a nonce before anyone signs.
+	signers := make([]*taproot.Signer, len(privKeys))
+	var nonces []NonceEntry
+	for i, priv := range privKeys {
+		signer, err := taproot.NewSigner(priv, pubKeys, msgHash)
+		if err != nil {
+			return fmt.Errorf("failed to start signing session for key %d: %w", i, err)
+		}
+		signers[i] = signer
+
+		nonce := signer.PubNonce()
+		nonces = append(nonces, NonceEntry{
+			Pubkey: hex.EncodeToString(priv.PubKey().SerializeCompressed()),
+			Nonce:  hex.EncodeToString(nonce[:]),
+		})
+	}
+	if err := writeJSONFile("nonces.json", nonces); err != nil {
+		return fmt.Errorf("failed to write nonces.json: %w", err)
+	}
+
+	// Round 2: every signer, having seen every nonce, produces its
+	// partial signature.
+	var partialSigs []PartialSigEntry
+	partialSigObjs := make([]*musig2.PartialSignature, len(signers))
+	for i, signer := range signers {
+		var otherNonces [][musig2.PubNonceSize]byte
+		for j, n := range nonces {
+			if j == i {
+				continue
+			}
+			decoded, err := hex.DecodeString(n.Nonce)
+			if err != nil {
+				return fmt.Errorf("invalid nonce: %w", err)
+			}
+			var raw [musig2.PubNonceSize]byte
+			copy(raw[:], decoded)
+			otherNonces = append(otherNonces, raw)
+		}
+
+		sig, err := signer.Sign(otherNonces)
+		if err != nil {
+			return fmt.Errorf("failed to produce partial signature for key %d: %w", i, err)
+		}
+		partialSigObjs[i] = sig
+
+		encoded, err := encodePartialSig(sig)
+		if err != nil {
+			return err
+		}
+		partialSigs = append(partialSigs, PartialSigEntry{
+			Pubkey: nonces[i].Pubkey,
+			Sig:    encoded,
+		})
+	}
+	if err := writeJSONFile("partial_sigs.json", partialSigs); err != nil {
+		return fmt.Errorf("failed to write partial_sigs.json: %w", err)
+	}
+
+	// Any signer's session has now seen every nonce, so feed it every
+	// other signer's partial signature (as read back from
+	// partial_sigs.json) to obtain the final aggregate signature.
+	finalSig, err := combineFromFile(signers[0], partialSigs, 0)
+	if err != nil {
+		return fmt.Errorf("failed to aggregate final signature: %w", err)
+	}
+
+	witness := wire.TxWitness{finalSig.Serialize()}
+	tx.TxIn[0].Witness = witness
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return fmt.Errorf("failed to serialize signed tx: %w", err)
+	}
+	signedHex := hex.EncodeToString(buf.Bytes())
+
+	if err := os.WriteFile(outFile, []byte(signedHex), 0644); err != nil {
+		return fmt.Errorf("failed to write signed tx: %w", err)
+	}
+
+	fmt.Printf("✅ Signed TX (hex): %s\n", signedHex)
+	fmt.Println("→ signed tx written to:", outFile)
+
+	return nil
+}
+
+// combineFromFile decodes every partial signature in entries other than
+// ownIndex (which signer already produced locally) and combines them
+// into the final 64-byte Schnorr signature.
+func combineFromFile(signer *taproot.Signer, entries []PartialSigEntry, ownIndex int) (*schnorr.Signature, error) {
+	var others []*musig2.PartialSignature
+	for i, e := range entries {
+		if i == ownIndex {
+			continue
+		}
+		sig, err := decodePartialSig(e.Sig)
+		if err != nil {
+			return nil, err
+		}
+		others = append(others, sig)
+	}
+	return signer.CombineSigs(others)
+}
+
+// runTaprootScriptPath signs the m-of-n tapscript leaf spend with plain
+// per-key Schnorr signatures (no MuSig2 needed on the script path) and
+// assembles the witness stack: the m signatures, the leaf script and its
+// control block.
+func runTaprootScriptPath(txHex, address, privFile, outFile string, amount int64, threshold int, net *chaincfg.Params) error {
+	tx, err := decodeTxHex(txHex)
+	if err != nil {
+		return err
+	}
+
+	privKeys, err := loadPrivKeys(privFile)
+	if err != nil {
+		return err
+	}
+	if len(privKeys) < threshold {
+		return fmt.Errorf("only %d keys available, need %d", len(privKeys), threshold)
+	}
+
+	var pubKeys []*btcec.PublicKey
+	for _, p := range privKeys {
+		pubKeys = append(pubKeys, p.PubKey())
+	}
+
+	_, tree, outputKey, err := taproot.ScriptPathAddress(pubKeys, threshold, net)
+	if err != nil {
+		return fmt.Errorf("failed to derive script-path address: %w", err)
+	}
+	leaf, err := taproot.MultisigLeafScript(pubKeys, threshold)
+	if err != nil {
+		return fmt.Errorf("failed to build multisig leaf script: %w", err)
+	}
+
+	fetcher, err := taprootPrevOutFetcher(address, amount, net)
+	if err != nil {
+		return err
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+
+	sigMsg, err := txscript.CalcTapscriptSignaturehash(
+		sigHashes, txscript.SigHashDefault, tx, 0, fetcher, leaf,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to compute tapscript sighash: %w", err)
+	}
+	var msgHash [32]byte
+	copy(msgHash[:], sigMsg)
+
+	// Witness order must match the OP_CHECKSIG/OP_CHECKSIGADD order in
+	// the leaf script, so push an empty element for any key we didn't
+	// sign with.
+	sigs := make([][]byte, len(pubKeys))
+	for i, priv := range privKeys {
+		if i >= threshold {
+			break
+		}
+		sig, err := schnorr.Sign(priv, msgHash[:])
+		if err != nil {
+			return fmt.Errorf("failed to sign with key %d: %w", i, err)
+		}
+		sigs[i] = sig.Serialize()
+	}
+
+	outputKeyYIsOdd := outputKey.SerializeCompressed()[0] == 0x03
+	controlBlock, err := taproot.ControlBlock(tree, 0, outputKeyYIsOdd)
+	if err != nil {
+		return fmt.Errorf("failed to build control block: %w", err)
+	}
+
+	var witness wire.TxWitness
+	for i := len(sigs) - 1; i >= 0; i-- {
+		if sigs[i] == nil {
+			witness = append(witness, []byte{})
+			continue
+		}
+		witness = append(witness, sigs[i])
+	}
+	witness = append(witness, leaf.Script)
+	witness = append(witness, controlBlock)
+	tx.TxIn[0].Witness = witness
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return fmt.Errorf("failed to serialize signed tx: %w", err)
+	}
+	signedHex := hex.EncodeToString(buf.Bytes())
+
+	if err := os.WriteFile(outFile, []byte(signedHex), 0644); err != nil {
+		return fmt.Errorf("failed to write signed tx: %w", err)
+	}
+
+	fmt.Printf("✅ Signed TX (hex): %s\n", signedHex)
+	fmt.Println("→ signed tx written to:", outFile)
+
+	return nil
+}
+
+func writeJSONFile(filename string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0600)
+}
@@ -9,15 +9,36 @@ import (
 	"log"
 	"strings"
 
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+
+	"github.com/halseth/multisig-check/netparams"
+	"github.com/halseth/multisig-check/sigcache"
 )
 
 var PREVOUT_PREFIX = []byte("txid random prefix")
 
+// sigCacheSize bounds the verifier's shared signature cache. A single CLI
+// invocation rarely has enough repeat signatures to fill this, but it
+// keeps the cache well-behaved if -tx/-hex is used to batch-verify many
+// transactions at once.
+const sigCacheSize = 100000
+
+type arrayFlags []string
+
+func (i *arrayFlags) String() string {
+	return fmt.Sprintf("%v", *i)
+}
+
+func (i *arrayFlags) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
+
 func decodeTx(txHex string) (*wire.MsgTx, error) {
 	raw, err := hex.DecodeString(txHex)
 	if err != nil {
@@ -30,13 +51,15 @@ func decodeTx(txHex string) (*wire.MsgTx, error) {
 	return tx, nil
 }
 
-func getScriptPubKeyFromAddress(address string) ([]byte, error) {
-	addr, err := btcutil.DecodeAddress(address, &chaincfg.MainNetParams)
+func getScriptPubKeyFromAddress(address string, net *chaincfg.Params) ([]byte, error) {
+	addr, err := btcutil.DecodeAddress(address, net)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode address: %w", err)
 	}
-	if _, ok := addr.(*btcutil.AddressWitnessScriptHash); !ok {
-		return nil, errors.New("address must be a P2WSH address")
+	switch addr.(type) {
+	case *btcutil.AddressWitnessScriptHash, *btcutil.AddressTaproot:
+	default:
+		return nil, errors.New("address must be a P2WSH or P2TR address")
 	}
 	script, err := txscript.PayToAddrScript(addr)
 	if err != nil {
@@ -47,62 +70,111 @@ func getScriptPubKeyFromAddress(address string) ([]byte, error) {
 
 func main() {
 	var (
-		txHex      string
-		hexStr     string
+		addrType   string
+		txHexes    arrayFlags
+		hexStrs    arrayFlags
 		address    string
 		amountSats int64 = 1000
+		network    string
+		ackNoBatch bool
 	)
 
-	flag.StringVar(&txHex, "tx", "", "Signed transaction hex")
-	flag.StringVar(&hexStr, "hex", "", "32-byte random hex string (for double SHA256 prevout)")
-	flag.StringVar(&address, "address", "", "P2WSH address being spent from")
+	flag.StringVar(&addrType, "type", "p2wsh", "Output type: p2wsh, p2tr-keypath or p2tr-scriptpath")
+	flag.Var(&txHexes, "tx", "Signed transaction hex (repeatable, to batch-verify several spends)")
+	flag.Var(&hexStrs, "hex", "32-byte random hex string used as the prevout seed, one per -tx")
+	flag.StringVar(&address, "address", "", "Address being spent from")
+	flag.Int64Var(&amountSats, "amount", 1000, "Amount of the input being spent, in sats")
+	flag.StringVar(&network, "network", "mainnet", "Network: mainnet, testnet, signet or regtest")
+	flag.BoolVar(&ackNoBatch, "ack-no-batch-crypto", false, "Required for -type p2tr-keypath: acknowledges that sigcache.VerifyBatch is cache-only (no libsecp256k1 batch API is wired in) before proceeding")
 	flag.Parse()
 
-	if hexStr == "" || txHex == "" || address == "" || amountSats <= 0 {
+	if len(txHexes) == 0 || len(txHexes) != len(hexStrs) || address == "" || amountSats <= 0 {
 		flag.Usage()
-		log.Fatal("All flags are required")
+		log.Fatal("-tx and -hex must be given an equal, non-zero number of times, along with -address and -amount")
+	}
+	if addrType != "p2wsh" && addrType != "p2tr-keypath" && addrType != "p2tr-scriptpath" {
+		log.Fatalf("❌ Error: unknown -type %q", addrType)
+	}
+	if addrType == "p2tr-keypath" && !ackNoBatch {
+		log.Fatal("❌ Error: -type p2tr-keypath runs sigcache.VerifyBatch, which is cache-only (see sigcache package doc) " +
+			"since btcec/v2 exposes no libsecp256k1 batch-verification API; pass -ack-no-batch-crypto to proceed anyway")
 	}
 
-	tx, err := decodeTx(txHex)
+	net, err := netparams.Parse(network)
 	if err != nil {
-		log.Fatalf("❌ Transaction decode error: %v", err)
+		log.Fatalf("❌ Error: %v", err)
+	}
+
+	cache := sigcache.New(sigCacheSize)
+
+	var batch []sigcache.SchnorrBatchItem
+	for i := range txHexes {
+		items, err := verifyOne(txHexes[i], hexStrs[i], address, addrType, amountSats, cache, net)
+		if err != nil {
+			log.Fatalf("❌ [tx %d] %v", i, err)
+		}
+		batch = append(batch, items...)
 	}
 
-	fmt.Println("Witness stack:")
-	for i, w := range tx.TxIn[0].Witness {
-		fmt.Printf("  [%d] %x (len=%d)\n", i, w, len(w))
+	// cache was already wired into every verifyOne call's script engine
+	// above, so each key-path signature here is already cached from its
+	// own transaction's verification: VerifyBatch's per-item cache
+	// lookup will hit for all of them, and no signature pays for a
+	// second elliptic-curve check. hits is only reported for visibility
+	// into how much of that work VerifyBatch actually skipped.
+	if addrType == "p2tr-keypath" {
+		hits := 0
+		for _, item := range batch {
+			if cache.Exists(item.SigHash, item.Sig.Serialize(), schnorr.SerializePubKey(item.PubKey)) {
+				hits++
+			}
+		}
+		if err := sigcache.VerifyBatch(cache, batch); err != nil {
+			log.Fatalf("❌ Batch signature verification failed: %v", err)
+		}
+		fmt.Printf("✅ Verified %d key-path signature(s) via cache-only VerifyBatch (%d cache hit(s), no libsecp256k1 batch crypto)\n", len(batch), hits)
 	}
 
+	fmt.Printf("✅ Verified %d transaction(s)\n", len(txHexes))
+}
+
+// verifyOne runs full script verification of a single signed transaction
+// — wiring cache into the script engine so any signature it verifies is
+// cached and, conversely, a signature already known valid from an
+// earlier call skips the engine's own elliptic-curve check — and, for
+// key-path taproot spends, also returns its signature as a
+// sigcache.SchnorrBatchItem so the caller can fold it into a larger
+// batch-verification pass.
+func verifyOne(txHex, hexStr, address, addrType string, amountSats int64, cache *sigcache.Cache, net *chaincfg.Params) ([]sigcache.SchnorrBatchItem, error) {
+	tx, err := decodeTx(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("transaction decode error: %w", err)
+	}
 	if len(tx.TxIn) == 0 {
-		log.Fatal("❌ No inputs in transaction")
+		return nil, fmt.Errorf("no inputs in transaction")
 	}
 
-	// Ensure the outppoint points to the given hex string.
+	// Ensure the outpoint points to the given hex string.
 	rawBytes, err := hex.DecodeString(hexStr)
 	if err != nil {
-		log.Fatal("❌ invalid hex string: %w", err)
+		return nil, fmt.Errorf("invalid hex string: %w", err)
 	}
-
 	b := PREVOUT_PREFIX[:]
 	b = append(b, rawBytes...)
-
 	h := sha256.Sum256(b)
 	txid := chainhash.Hash(h)
 
 	outpoint := wire.NewOutPoint(&txid, 0)
 	if tx.TxIn[0].PreviousOutPoint != *outpoint {
-		log.Fatal("❌ wrong prevout")
+		return nil, fmt.Errorf("wrong prevout")
 	}
 
-	scriptPubKey, err := getScriptPubKeyFromAddress(address)
+	scriptPubKey, err := getScriptPubKeyFromAddress(address, net)
 	if err != nil {
-		log.Fatalf("❌ ScriptPubKey error: %v", err)
+		return nil, fmt.Errorf("scriptPubKey error: %w", err)
 	}
 
-	prevOutFetcher := txscript.NewCannedPrevOutputFetcher(
-		scriptPubKey, amountSats,
-	)
-
+	prevOutFetcher := txscript.NewCannedPrevOutputFetcher(scriptPubKey, amountSats)
 	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
 
 	inputIndex := 0
@@ -111,18 +183,45 @@ func main() {
 		tx,
 		inputIndex,
 		txscript.StandardVerifyFlags,
-		nil,
+		cache,
 		sigHashes,
 		amountSats,
 		prevOutFetcher,
 	)
 	if err != nil {
-		log.Fatalf("❌ Failed to create script engine: %v", err)
+		return nil, fmt.Errorf("failed to create script engine: %w", err)
 	}
-
 	if err := vm.Execute(); err != nil {
-		log.Fatalf("❌ Witness verification failed: %v", err)
+		return nil, fmt.Errorf("witness verification failed: %w", err)
+	}
+
+	if addrType != "p2tr-keypath" {
+		return nil, nil
 	}
 
-	fmt.Println("✅ Witness verification succeeded.")
+	// A key-path spend's witness is a single 64 or 65-byte Schnorr
+	// signature over the taproot output key; pull it out so it can also
+	// be checked (and cached) as part of a batch.
+	sig, err := schnorr.ParseSignature(tx.TxIn[0].Witness[0][:64])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key-path signature: %w", err)
+	}
+	pubKey, err := schnorr.ParsePubKey(scriptPubKey[2:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse taproot output key: %w", err)
+	}
+	sigHashBytes, err := txscript.CalcTaprootSignatureHash(
+		sigHashes, txscript.SigHashDefault, tx, inputIndex, prevOutFetcher,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute taproot sighash: %w", err)
+	}
+	var sigHash chainhash.Hash
+	copy(sigHash[:], sigHashBytes)
+
+	return []sigcache.SchnorrBatchItem{{
+		SigHash: sigHash,
+		Sig:     sig,
+		PubKey:  pubKey,
+	}}, nil
 }
@@ -0,0 +1,120 @@
+// Package coinselect picks which UTXOs to spend for a target output
+// amount plus fee, and computes the resulting change. It knows nothing
+// about scripts or signing; callers provide per-input/output vsize
+// estimates so the same selection logic works for P2WSH, P2TR or any
+// other script type.
+package coinselect
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Utxo is a candidate coin: the amount available, plus the derivation
+// index needed to re-derive its owning key(s) from a ranged descriptor.
+type Utxo struct {
+	Txid   string
+	Vout   uint32
+	Amount int64
+	Index  uint32
+}
+
+// Params sizes the transaction for fee estimation: the vsize contributed
+// by the fixed transaction overhead (version, locktime, varint counts),
+// by each selected input (including its witness), and by each output.
+type Params struct {
+	OverheadVBytes int64
+	InputVBytes    int64
+	OutputVBytes   int64
+}
+
+// EstimateFee returns the fee, in satoshis, for a transaction with
+// numInputs inputs and numOutputs outputs at feeRate sat/vbyte.
+func (p Params) EstimateFee(numInputs, numOutputs int, feeRate int64) int64 {
+	vsize := p.OverheadVBytes + int64(numInputs)*p.InputVBytes + int64(numOutputs)*p.OutputVBytes
+	return vsize * feeRate
+}
+
+// Result is the outcome of a selection: which UTXOs to spend and how
+// much change (if any) is left over after paying targetAmount plus fees.
+type Result struct {
+	Selected []Utxo
+	Change   int64
+}
+
+// LargestFirst selects UTXOs largest-amount-first until their sum covers
+// targetAmount plus the fee for the inputs selected so far (assuming one
+// extra change output). It's simple and predictable, at the cost of
+// sometimes leaving more UTXOs fragmented than branch-and-bound would.
+func LargestFirst(utxos []Utxo, targetAmount int64, feeRate int64, numOutputs int, params Params) (*Result, error) {
+	sorted := append([]Utxo{}, utxos...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Amount > sorted[j].Amount })
+
+	var (
+		selected []Utxo
+		total    int64
+	)
+	for _, u := range sorted {
+		selected = append(selected, u)
+		total += u.Amount
+
+		fee := params.EstimateFee(len(selected), numOutputs+1, feeRate)
+		if total >= targetAmount+fee {
+			return &Result{Selected: selected, Change: total - targetAmount - fee}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("insufficient funds: have %d, need at least %d plus fees", total, targetAmount)
+}
+
+// BranchAndBound looks for a subset of utxos that covers targetAmount
+// plus fees with exactly zero change (costOfChange is 0, so any leftover
+// at all rules a subset out), avoiding a change output entirely when
+// possible. It falls back to returning an error (letting
+// the caller retry with LargestFirst) if no combination is found within
+// maxTries.
+func BranchAndBound(utxos []Utxo, targetAmount int64, feeRate int64, numOutputs int, params Params) (*Result, error) {
+	const maxTries = 100000
+	const costOfChange = 0 // treat any non-negative leftover under this as "free" to drop
+
+	sorted := append([]Utxo{}, utxos...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Amount > sorted[j].Amount })
+
+	var (
+		tries     int
+		best      []Utxo
+		bestWaste int64 = -1
+	)
+
+	var search func(idx int, selected []Utxo, sum int64)
+	search = func(idx int, selected []Utxo, sum int64) {
+		tries++
+		if tries > maxTries {
+			return
+		}
+
+		fee := params.EstimateFee(len(selected), numOutputs, feeRate)
+		if sum >= targetAmount+fee {
+			waste := sum - targetAmount - fee
+			if waste <= costOfChange && (bestWaste < 0 || waste < bestWaste) {
+				best = append([]Utxo{}, selected...)
+				bestWaste = waste
+			}
+			return
+		}
+		if idx >= len(sorted) {
+			return
+		}
+
+		// Include sorted[idx].
+		search(idx+1, append(selected, sorted[idx]), sum+sorted[idx].Amount)
+		// Exclude sorted[idx].
+		search(idx+1, selected, sum)
+	}
+	search(0, nil, 0)
+
+	if best == nil {
+		return nil, fmt.Errorf("no exact-ish match found within %d tries", maxTries)
+	}
+	return &Result{Selected: best, Change: bestWaste}, nil
+}
@@ -0,0 +1,79 @@
+package coinselect
+
+import "testing"
+
+func TestEstimateFee(t *testing.T) {
+	params := Params{OverheadVBytes: 10, InputVBytes: 60, OutputVBytes: 30}
+
+	// vsize = 10 + 2*60 + 2*30 = 190, fee = 190*2 = 380.
+	if got, want := params.EstimateFee(2, 2, 2), int64(380); got != want {
+		t.Errorf("EstimateFee(2, 2, 2) = %d, want %d", got, want)
+	}
+}
+
+func TestLargestFirst(t *testing.T) {
+	params := Params{OverheadVBytes: 10, InputVBytes: 60, OutputVBytes: 30}
+	utxos := []Utxo{
+		{Txid: "a", Amount: 5000},
+		{Txid: "b", Amount: 3000},
+		{Txid: "c", Amount: 1000},
+	}
+
+	// Largest-first tries the 5000 UTXO alone first: vsize = 10+60+60 =
+	// 130, fee = 260, but 5000 < 6000+260, so it isn't enough. Adding the
+	// 3000 UTXO: vsize = 10+120+60 = 190, fee = 380, and 8000 >= 6000+380,
+	// so selection stops there with change = 8000-6000-380 = 1620.
+	res, err := LargestFirst(utxos, 6000, 2, 1, params)
+	if err != nil {
+		t.Fatalf("LargestFirst: %v", err)
+	}
+	if len(res.Selected) != 2 {
+		t.Fatalf("selected %d utxos, want 2", len(res.Selected))
+	}
+	if res.Selected[0].Txid != "a" || res.Selected[1].Txid != "b" {
+		t.Fatalf("selected %v, want [a b]", res.Selected)
+	}
+	if res.Change != 1620 {
+		t.Errorf("change = %d, want 1620", res.Change)
+	}
+}
+
+func TestLargestFirstInsufficientFunds(t *testing.T) {
+	params := Params{OverheadVBytes: 10, InputVBytes: 60, OutputVBytes: 30}
+	utxos := []Utxo{{Txid: "a", Amount: 100}}
+
+	if _, err := LargestFirst(utxos, 1000000, 2, 1, params); err == nil {
+		t.Fatal("expected an insufficient funds error")
+	}
+}
+
+func TestBranchAndBoundExactMatch(t *testing.T) {
+	params := Params{OverheadVBytes: 10, InputVBytes: 20, OutputVBytes: 30}
+	utxos := []Utxo{
+		{Txid: "a", Amount: 1000},
+		{Txid: "b", Amount: 500},
+	}
+
+	// Selecting just "a": vsize = 10+20+30 = 60, fee = 60, and
+	// 1000 == 940+60 exactly, i.e. zero change. No other subset does
+	// better, so branch-and-bound should land on exactly that one.
+	res, err := BranchAndBound(utxos, 940, 1, 1, params)
+	if err != nil {
+		t.Fatalf("BranchAndBound: %v", err)
+	}
+	if len(res.Selected) != 1 || res.Selected[0].Txid != "a" {
+		t.Fatalf("selected %v, want [a]", res.Selected)
+	}
+	if res.Change != 0 {
+		t.Errorf("change = %d, want 0", res.Change)
+	}
+}
+
+func TestBranchAndBoundNoMatch(t *testing.T) {
+	params := Params{OverheadVBytes: 10, InputVBytes: 20, OutputVBytes: 30}
+	utxos := []Utxo{{Txid: "a", Amount: 100}}
+
+	if _, err := BranchAndBound(utxos, 1000000, 1, 1, params); err == nil {
+		t.Fatal("expected a no-match error")
+	}
+}
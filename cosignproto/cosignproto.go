@@ -0,0 +1,90 @@
+// Package cosignproto is the wire protocol between the coordinator and
+// cosigner binaries: an mTLS connection over which the coordinator sends
+// an unsigned PSBT and the cosigner returns it back with its own
+// PARTIAL_SIG fields added.
+package cosignproto
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+
+	ourpsbt "github.com/halseth/multisig-check/psbt"
+)
+
+// maxMessageSize bounds a single PSBT message, generous for an m-of-n
+// multisig but not unbounded, so a misbehaving peer can't force
+// unbounded memory allocation.
+const maxMessageSize = 10 << 20 // 10 MiB
+
+// TLSConfig loads certFile/keyFile as this peer's identity and caFile as
+// the single CA trusted to have signed the other side's certificate,
+// building the symmetric mTLS configuration both the coordinator (as TLS
+// client) and cosigner (as TLS server) use.
+func TLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate/key: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("failed to parse CA certificate %s", caFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}, nil
+}
+
+// WritePSBT sends packet over conn as a 4-byte big-endian length prefix
+// followed by its base64-encoded bytes.
+func WritePSBT(conn io.Writer, packet *psbt.Packet) error {
+	encoded, err := ourpsbt.Encode(packet)
+	if err != nil {
+		return err
+	}
+
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(encoded)))
+	if _, err := conn.Write(lenBuf[:]); err != nil {
+		return fmt.Errorf("failed to write message length: %w", err)
+	}
+	if _, err := conn.Write(encoded); err != nil {
+		return fmt.Errorf("failed to write PSBT: %w", err)
+	}
+	return nil
+}
+
+// ReadPSBT reads a PSBT sent by WritePSBT from conn.
+func ReadPSBT(conn io.Reader) (*psbt.Packet, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(conn, lenBuf[:]); err != nil {
+		return nil, fmt.Errorf("failed to read message length: %w", err)
+	}
+
+	msgLen := binary.BigEndian.Uint32(lenBuf[:])
+	if msgLen == 0 || msgLen > maxMessageSize {
+		return nil, fmt.Errorf("invalid message length %d", msgLen)
+	}
+
+	data := make([]byte, msgLen)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return nil, fmt.Errorf("failed to read PSBT: %w", err)
+	}
+
+	return ourpsbt.Decode(data)
+}
@@ -0,0 +1,127 @@
+package descriptor
+
+import "fmt"
+
+// This file implements the BIP-380 descriptor checksum: a BCH-style code
+// over a 5-bit alphabet, ported directly from the reference
+// implementation in Bitcoin Core's src/script/descriptor.cpp.
+
+const inputCharset = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+const checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var generator = [5]uint64{
+	0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd,
+}
+
+func polyMod(symbols []int) uint64 {
+	var chk uint64 = 1
+	for _, value := range symbols {
+		top := chk >> 35
+		chk = (chk&0x7ffffffff)<<5 ^ uint64(value)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 != 0 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func expand(s string) ([]int, error) {
+	var symbols []int
+	var groups []int
+	for _, c := range s {
+		idx := indexOf(inputCharset, c)
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid descriptor character %q", c)
+		}
+		symbols = append(symbols, idx&31)
+		groups = append(groups, idx>>5)
+		if len(groups) == 3 {
+			symbols = append(symbols, groups[0]*9+groups[1]*3+groups[2])
+			groups = nil
+		}
+	}
+	switch len(groups) {
+	case 1:
+		symbols = append(symbols, groups[0])
+	case 2:
+		symbols = append(symbols, groups[0]*3+groups[1])
+	}
+	return symbols, nil
+}
+
+func indexOf(charset string, c rune) int {
+	for i, r := range charset {
+		if r == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// Checksum computes the 8-character BIP-380 checksum for a descriptor
+// string without its "#checksum" suffix.
+func Checksum(desc string) (string, error) {
+	symbols, err := expand(desc)
+	if err != nil {
+		return "", err
+	}
+	symbols = append(symbols, 0, 0, 0, 0, 0, 0, 0, 0)
+
+	checksum := polyMod(symbols) ^ 1
+
+	out := make([]byte, 8)
+	for i := 0; i < 8; i++ {
+		out[i] = checksumCharset[(checksum>>(5*(7-uint(i))))&31]
+	}
+	return string(out), nil
+}
+
+// AppendChecksum returns desc with a "#checksum" suffix appended.
+func AppendChecksum(desc string) (string, error) {
+	sum, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+	return desc + "#" + sum, nil
+}
+
+// VerifyChecksum reports whether desc (which must be of the form
+// "<descriptor>#<8-char checksum>") carries a valid BIP-380 checksum.
+func VerifyChecksum(desc string) error {
+	parts := splitOnce(desc, '#')
+	if len(parts) != 2 {
+		return fmt.Errorf("descriptor is missing a '#' checksum")
+	}
+	body, checksum := parts[0], parts[1]
+	if len(checksum) != 8 {
+		return fmt.Errorf("descriptor checksum must be 8 characters, got %d", len(checksum))
+	}
+
+	symbols, err := expand(body)
+	if err != nil {
+		return err
+	}
+	for _, c := range checksum {
+		idx := indexOf(checksumCharset, c)
+		if idx < 0 {
+			return fmt.Errorf("invalid checksum character %q", c)
+		}
+		symbols = append(symbols, idx)
+	}
+
+	if polyMod(symbols) != 1 {
+		return fmt.Errorf("descriptor checksum mismatch")
+	}
+	return nil
+}
+
+func splitOnce(s string, sep byte) []string {
+	for i := 0; i < len(s); i++ {
+		if s[i] == sep {
+			return []string{s[:i], s[i+1:]}
+		}
+	}
+	return []string{s}
+}
@@ -0,0 +1,115 @@
+package descriptor
+
+import "testing"
+
+// These expected checksums were cross-checked against an independent
+// Python re-implementation of the BIP-380 algorithm (same generator
+// polynomial and charsets, written from the spec rather than copied from
+// this file), not just recomputed from Checksum itself.
+func TestChecksumKnownVectors(t *testing.T) {
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{
+			desc: "addr(bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4)",
+			want: "uyjndxcw",
+		},
+		{
+			desc: "pk(0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798)",
+			want: "gn28ywm7",
+		},
+		{
+			desc: "pkh(0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798)",
+			want: "e48zzw02",
+		},
+		{
+			desc: "wpkh(0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798)",
+			want: "ucxz0gak",
+		},
+	}
+	for _, tc := range tests {
+		got, err := Checksum(tc.desc)
+		if err != nil {
+			t.Errorf("Checksum(%q): %v", tc.desc, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Checksum(%q) = %q, want %q", tc.desc, got, tc.want)
+		}
+	}
+}
+
+// TestChecksumRoundTrip checks that AppendChecksum produces a descriptor
+// that VerifyChecksum accepts.
+func TestChecksumRoundTrip(t *testing.T) {
+	descs := []string{
+		"addr(bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4)",
+		"sh(wpkh(0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798))",
+		"multi(1,0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798,03fff97bd5755eeea420453a14355235d382f6472f8568a18b2f057a1460297556)",
+	}
+	for _, desc := range descs {
+		full, err := AppendChecksum(desc)
+		if err != nil {
+			t.Fatalf("AppendChecksum(%q): %v", desc, err)
+		}
+		if err := VerifyChecksum(full); err != nil {
+			t.Errorf("VerifyChecksum(%q): %v", full, err)
+		}
+	}
+}
+
+// TestChecksumDetectsCorruption confirms the BCH code actually catches a
+// single changed character, whether in the descriptor body or in the
+// checksum itself — the property that makes this a useful error-detecting
+// code rather than decoration.
+func TestChecksumDetectsCorruption(t *testing.T) {
+	full, err := AppendChecksum("wpkh(0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798)")
+	if err != nil {
+		t.Fatalf("AppendChecksum: %v", err)
+	}
+
+	for i := 0; i < len(full); i++ {
+		if full[i] == '#' {
+			continue
+		}
+		mutated := []byte(full)
+		// Rotate the character at i to a different lowercase letter or
+		// digit. In the body that stays within the input charset; in the
+		// checksum it may land outside the checksum charset, which
+		// VerifyChecksum rejects as an invalid character.
+		orig := mutated[i]
+		mutated[i] = rotateChar(orig)
+		if mutated[i] == orig {
+			continue
+		}
+		if err := VerifyChecksum(string(mutated)); err == nil {
+			t.Errorf("VerifyChecksum did not detect corruption at index %d (%q -> %q)", i, full, mutated)
+		}
+	}
+}
+
+// rotateChar maps a byte to a different lowercase letter or digit. A
+// corrupted body character stays within the input charset, exercising
+// checksum mismatch detection rather than a parse error; a corrupted
+// checksum character may instead fall outside the checksum charset
+// (e.g. '0' -> '1', 'a' -> 'b').
+func rotateChar(b byte) byte {
+	switch {
+	case b >= 'a' && b <= 'z':
+		return 'a' + (b-'a'+1)%26
+	case b >= '0' && b <= '9':
+		return '0' + (b-'0'+1)%10
+	default:
+		return 'a'
+	}
+}
+
+func TestVerifyChecksumRejectsBadInput(t *testing.T) {
+	if err := VerifyChecksum("wpkh(abc)"); err == nil {
+		t.Error("expected error for descriptor with no '#' checksum")
+	}
+	if err := VerifyChecksum("wpkh(abc)#short"); err == nil {
+		t.Error("expected error for checksum with wrong length")
+	}
+}
@@ -0,0 +1,706 @@
+// Package descriptor implements a minimal BIP-380 output descriptor
+// parser and emitter: enough of the `wsh`/`sh`/`wpkh`/`tr`/`sortedmulti`/
+// `multi`/`sortedmulti_a`/`multi_a` grammar, key origins
+// (`[fingerprint/path]`) and ranged (`/*`) children to describe the
+// P2WSH and P2TR script-path multisig setups this tool generates, with
+// the same checksum Bitcoin Core, Sparrow, Electrum and HWI expect.
+package descriptor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/halseth/multisig-check/slip132"
+	"github.com/halseth/multisig-check/taproot"
+)
+
+// Fingerprint returns the BIP32 fingerprint of key: the first four
+// bytes of HASH160(compressed pubkey), used to identify a key origin in
+// the "[fingerprint/path]" portion of a key expression.
+func Fingerprint(key *hdkeychain.ExtendedKey) (uint32, error) {
+	pub, err := key.ECPubKey()
+	if err != nil {
+		return 0, err
+	}
+	hash := btcutil.Hash160(pub.SerializeCompressed())
+	return binary.BigEndian.Uint32(hash[:4]), nil
+}
+
+// SplitHardenedPrefix splits path into its leading hardened components
+// (the portion that can only be derived from a private key, such as an
+// account path) and the remaining non-hardened suffix (safe to derive
+// from an xpub, such as a change/address-index path).
+func SplitHardenedPrefix(path []uint32) (prefix, suffix []uint32) {
+	i := 0
+	for i < len(path) && path[i] >= hdkeychain.HardenedKeyStart {
+		i++
+	}
+	return path[:i], path[i:]
+}
+
+// ScriptType identifies the top-level descriptor function wrapping the
+// key expression(s).
+type ScriptType string
+
+const (
+	WSH  ScriptType = "wsh"
+	SH   ScriptType = "sh"
+	WPKH ScriptType = "wpkh"
+	TR   ScriptType = "tr"
+)
+
+// Key is one key expression inside a descriptor: an optional origin (the
+// master key fingerprint and the path used to reach the embedded
+// extended key) plus the extended key itself and the path still to be
+// derived from it, which may end in a wildcard ("/*").
+type Key struct {
+	Fingerprint uint32
+	OriginPath  []uint32
+	XKey        string
+	ChildPath   []uint32
+	Ranged      bool
+}
+
+// FullPath returns the complete derivation path from the master key to
+// a derived child at the given index (used in place of the wildcard, if
+// any).
+func (k Key) FullPath(index uint32) []uint32 {
+	path := append(append([]uint32{}, k.OriginPath...), k.ChildPath...)
+	if k.Ranged {
+		path = append(path, index)
+	}
+	return path
+}
+
+// IndexFromPath recovers the derivation index implied by a full BIP32
+// path previously produced by FullPath, e.g. one read back from a PSBT's
+// BIP32_DERIVATION field. It returns an error if path isn't one this key
+// could have produced.
+func (k Key) IndexFromPath(path []uint32) (uint32, error) {
+	want := append(append([]uint32{}, k.OriginPath...), k.ChildPath...)
+	if !k.Ranged {
+		if !pathEqual(path, want) {
+			return 0, fmt.Errorf("path %v does not match key origin/child path %v", path, want)
+		}
+		return 0, nil
+	}
+
+	if len(path) != len(want)+1 || !pathEqual(path[:len(want)], want) {
+		return 0, fmt.Errorf("path %v does not extend key origin/child path %v", path, want)
+	}
+	return path[len(want)], nil
+}
+
+func pathEqual(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// literalPubKey recognizes a key expression that is a bare hex-encoded
+// public key (32-byte x-only or 33-byte compressed) rather than an
+// extended key, as used for tr()'s internal key and the leaf keys inside
+// multi_a()/sortedmulti_a(), which are typically not HD xpubs.
+func literalPubKey(s string) ([]byte, bool) {
+	if len(s) != 64 && len(s) != 66 {
+		return nil, false
+	}
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	return raw, true
+}
+
+// DerivePubKey derives the compressed public key this key expression
+// resolves to at the given index (ignored unless the key is ranged). A
+// literal key expression is returned exactly as written: 32-byte x-only
+// or 33-byte compressed.
+func (k Key) DerivePubKey(index uint32) ([]byte, error) {
+	if raw, ok := literalPubKey(k.XKey); ok {
+		return raw, nil
+	}
+
+	// A key imported from a hardware wallet or another tool may use a
+	// SLIP-132 prefix (zpub, Zpub, ...) instead of a plain xpub/tpub;
+	// canonicalize it first since hdkeychain only recognizes the latter.
+	xkey, _, err := slip132.Canonicalize(k.XKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended key %q: %w", k.XKey, err)
+	}
+
+	extKey, err := hdkeychain.NewKeyFromString(xkey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended key %q: %w", k.XKey, err)
+	}
+
+	path := k.ChildPath
+	if k.Ranged {
+		path = append(append([]uint32{}, k.ChildPath...), index)
+	}
+	for _, i := range path {
+		extKey, err = extKey.Derive(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive child key: %w", err)
+		}
+	}
+
+	pub, err := extKey.ECPubKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pubkey: %w", err)
+	}
+	return pub.SerializeCompressed(), nil
+}
+
+// Multi is a sortedmulti()/multi() threshold expression.
+type Multi struct {
+	Threshold int
+	Sorted    bool
+	Keys      []Key
+}
+
+// Descriptor is a parsed BIP-380 output descriptor. InternalKey is only
+// set for Type == TR, and Multi is nil for a key-path-only tr() (no
+// script tree); every other type always carries a Multi.
+type Descriptor struct {
+	Type        ScriptType
+	InternalKey *Key
+	Multi       *Multi
+}
+
+// Parse parses a descriptor string, validating its checksum if one is
+// present.
+func Parse(desc string) (*Descriptor, error) {
+	body := desc
+	if idx := strings.IndexByte(desc, '#'); idx >= 0 {
+		if err := VerifyChecksum(desc); err != nil {
+			return nil, err
+		}
+		body = desc[:idx]
+	}
+
+	name, inner, err := splitFunc(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var scriptType ScriptType
+	switch name {
+	case "wsh":
+		scriptType = WSH
+	case "sh":
+		scriptType = SH
+	case "wpkh":
+		// wpkh() wraps a single bare key expression, not a
+		// (sorted)multi(...) call, so it can't go through parseMulti
+		// below; model it as a degenerate 1-of-1 Multi so
+		// DeriveAddressPubKeys derives its key like any other. String
+		// and DeriveScriptPubKey still handle WPKH separately.
+		key, err := parseKey(inner)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wpkh() contents: %w", err)
+		}
+		return &Descriptor{
+			Type:  WPKH,
+			Multi: &Multi{Threshold: 1, Keys: []Key{key}},
+		}, nil
+	case "tr":
+		return parseTR(inner)
+	default:
+		return nil, fmt.Errorf("unsupported descriptor function %q", name)
+	}
+
+	multi, err := parseMulti(inner)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s() contents: %w", name, err)
+	}
+
+	return &Descriptor{Type: scriptType, Multi: multi}, nil
+}
+
+// parseTR parses the contents of a tr(INTERNAL_KEY) or
+// tr(INTERNAL_KEY,TREE) expression. Only a single multi_a()/
+// sortedmulti_a() leaf is supported as TREE, matching the one-leaf
+// tapscript tree this tool builds; a key-path-only tr(INTERNAL_KEY) is
+// also accepted, leaving Multi nil.
+func parseTR(inner string) (*Descriptor, error) {
+	fields := splitTopLevel(inner, ',')
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("tr() requires an internal key and at most one script expression")
+	}
+
+	internalKey, err := parseKey(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid internal key %q: %w", fields[0], err)
+	}
+	desc := &Descriptor{Type: TR, InternalKey: &internalKey}
+
+	if len(fields) == 1 {
+		return desc, nil
+	}
+
+	multi, err := parseMultiA(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid tr() script expression: %w", err)
+	}
+	desc.Multi = multi
+
+	return desc, nil
+}
+
+// parseMultiFields parses "threshold,key,key,..." into a Multi with the
+// given sortedness, shared by parseMulti and parseMultiA.
+func parseMultiFields(sorted bool, funcName string, fields []string) (*Multi, error) {
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("%s() requires a threshold and at least one key", funcName)
+	}
+
+	threshold, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid threshold %q: %w", fields[0], err)
+	}
+
+	var keys []Key
+	for _, f := range fields[1:] {
+		key, err := parseKey(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key expression %q: %w", f, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if threshold <= 0 || threshold > len(keys) {
+		return nil, fmt.Errorf("invalid threshold %d for %d keys", threshold, len(keys))
+	}
+
+	return &Multi{Threshold: threshold, Sorted: sorted, Keys: keys}, nil
+}
+
+// parseMulti parses a "(sorted)multi(...)" expression, as used inside
+// wsh()/sh().
+func parseMulti(inner string) (*Multi, error) {
+	multiName, multiInner, err := splitFunc(inner)
+	if err != nil {
+		return nil, err
+	}
+
+	var sorted bool
+	switch multiName {
+	case "sortedmulti":
+		sorted = true
+	case "multi":
+		sorted = false
+	default:
+		return nil, fmt.Errorf("unsupported multisig function %q", multiName)
+	}
+
+	return parseMultiFields(sorted, multiName, splitTopLevel(multiInner, ','))
+}
+
+// parseMultiA parses a "(sorted)multi_a(...)" expression, the BIP-387
+// tapscript-leaf form used inside tr().
+func parseMultiA(inner string) (*Multi, error) {
+	multiName, multiInner, err := splitFunc(inner)
+	if err != nil {
+		return nil, err
+	}
+
+	var sorted bool
+	switch multiName {
+	case "sortedmulti_a":
+		sorted = true
+	case "multi_a":
+		sorted = false
+	default:
+		return nil, fmt.Errorf("unsupported multisig function %q", multiName)
+	}
+
+	return parseMultiFields(sorted, multiName, splitTopLevel(multiInner, ','))
+}
+
+// splitFunc splits "name(inner)" into its name and inner contents.
+func splitFunc(s string) (name, inner string, err error) {
+	open := strings.IndexByte(s, '(')
+	if open < 0 || s[len(s)-1] != ')' {
+		return "", "", fmt.Errorf("malformed descriptor expression %q", s)
+	}
+	return s[:open], s[open+1 : len(s)-1], nil
+}
+
+// splitTopLevel splits s on sep, ignoring separators nested inside ()
+// or [] so that a key's own path components aren't mistaken for field
+// boundaries.
+func splitTopLevel(s string, sep byte) []string {
+	var (
+		fields []string
+		depth  int
+		start  int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case sep:
+			if depth == 0 {
+				fields = append(fields, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	fields = append(fields, s[start:])
+	return fields
+}
+
+// parseKey parses a single key expression: an optional "[fpr/path]"
+// origin, an extended public key, and an optional "/path" (possibly
+// ending in "/*") suffix.
+func parseKey(s string) (Key, error) {
+	var key Key
+
+	if strings.HasPrefix(s, "[") {
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return key, fmt.Errorf("unterminated key origin")
+		}
+		origin := s[1:end]
+		s = s[end+1:]
+
+		segs := strings.Split(origin, "/")
+		if len(segs) == 0 || len(segs[0]) != 8 {
+			return key, fmt.Errorf("invalid master fingerprint %q", origin)
+		}
+		fpr, err := strconv.ParseUint(segs[0], 16, 32)
+		if err != nil {
+			return key, fmt.Errorf("invalid master fingerprint %q: %w", segs[0], err)
+		}
+		key.Fingerprint = uint32(fpr)
+
+		path, _, err := parsePathSegments(segs[1:])
+		if err != nil {
+			return key, err
+		}
+		key.OriginPath = path
+	}
+
+	segs := strings.Split(s, "/")
+	key.XKey = segs[0]
+	if len(segs) > 1 {
+		path, ranged, err := parsePathSegments(segs[1:])
+		if err != nil {
+			return key, err
+		}
+		key.ChildPath = path
+		key.Ranged = ranged
+	}
+
+	return key, nil
+}
+
+// parsePathSegments parses path components such as "84h", "0'" or the
+// trailing wildcard "*".
+func parsePathSegments(segs []string) (path []uint32, ranged bool, err error) {
+	for i, seg := range segs {
+		if seg == "*" {
+			if i != len(segs)-1 {
+				return nil, false, fmt.Errorf("wildcard must be the last path component")
+			}
+			ranged = true
+			break
+		}
+
+		hardened := strings.HasSuffix(seg, "h") || strings.HasSuffix(seg, "'") || strings.HasSuffix(seg, "H")
+		numStr := seg
+		if hardened {
+			numStr = seg[:len(seg)-1]
+		}
+		n, err := strconv.ParseUint(numStr, 10, 32)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid path component %q: %w", seg, err)
+		}
+		if hardened {
+			n += hdkeychain.HardenedKeyStart
+		}
+		path = append(path, uint32(n))
+	}
+	return path, ranged, nil
+}
+
+// formatPath renders path back into "84h/0h/0h" notation.
+func formatPath(path []uint32) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		if p >= hdkeychain.HardenedKeyStart {
+			parts[i] = fmt.Sprintf("%dh", p-hdkeychain.HardenedKeyStart)
+		} else {
+			parts[i] = strconv.FormatUint(uint64(p), 10)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// String renders k back into "[fpr/path]xkey/childpath" form.
+func (k Key) String() string {
+	var b strings.Builder
+	if len(k.OriginPath) > 0 || k.Fingerprint != 0 {
+		fmt.Fprintf(&b, "[%08x", k.Fingerprint)
+		if len(k.OriginPath) > 0 {
+			fmt.Fprintf(&b, "/%s", formatPath(k.OriginPath))
+		}
+		b.WriteByte(']')
+	}
+	b.WriteString(k.XKey)
+	if len(k.ChildPath) > 0 {
+		fmt.Fprintf(&b, "/%s", formatPath(k.ChildPath))
+	}
+	if k.Ranged {
+		b.WriteString("/*")
+	}
+	return b.String()
+}
+
+// String renders d back into its canonical (checksum-free) descriptor
+// form.
+func (d *Descriptor) String() string {
+	if d.Type == TR {
+		if d.Multi == nil {
+			return fmt.Sprintf("tr(%s)", d.InternalKey.String())
+		}
+		multiName := "multi_a"
+		if d.Multi.Sorted {
+			multiName = "sortedmulti_a"
+		}
+		return fmt.Sprintf(
+			"tr(%s,%s(%d,%s))", d.InternalKey.String(), multiName,
+			d.Multi.Threshold, strings.Join(keyStrings(d.Multi.Keys), ","),
+		)
+	}
+
+	if d.Type == WPKH {
+		return fmt.Sprintf("wpkh(%s)", d.Multi.Keys[0].String())
+	}
+
+	multiName := "multi"
+	if d.Multi.Sorted {
+		multiName = "sortedmulti"
+	}
+
+	return fmt.Sprintf(
+		"%s(%s(%d,%s))", d.Type, multiName, d.Multi.Threshold,
+		strings.Join(keyStrings(d.Multi.Keys), ","),
+	)
+}
+
+// keyStrings renders each key in keys with Key.String().
+func keyStrings(keys []Key) []string {
+	strs := make([]string, len(keys))
+	for i, k := range keys {
+		strs[i] = k.String()
+	}
+	return strs
+}
+
+// WithChecksum returns d's canonical form with its BIP-380 checksum
+// appended.
+func (d *Descriptor) WithChecksum() (string, error) {
+	return AppendChecksum(d.String())
+}
+
+// DeriveScriptPubKey derives the scriptPubKey that d resolves to at the
+// given index (only relevant if d's keys are ranged).
+func (d *Descriptor) DeriveScriptPubKey(index uint32, net *chaincfg.Params) ([]byte, error) {
+	if d.Type == TR {
+		return d.deriveTaprootScriptPubKey(index, net)
+	}
+
+	addrPubKeys, err := d.DeriveAddressPubKeys(index, net)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Type == WPKH {
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(
+			addrPubKeys[0].AddressPubKeyHash().Hash160()[:], net,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create P2WPKH address: %w", err)
+		}
+		return txscript.PayToAddrScript(addr)
+	}
+
+	redeemScript, err := txscript.MultiSigScript(addrPubKeys, d.Multi.Threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build multisig script: %w", err)
+	}
+
+	switch d.Type {
+	case WSH:
+		witnessProg := sha256.Sum256(redeemScript)
+		addr, err := btcutil.NewAddressWitnessScriptHash(
+			witnessProg[:], net,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create P2WSH address: %w", err)
+		}
+		return txscript.PayToAddrScript(addr)
+	case SH:
+		addr, err := btcutil.NewAddressScriptHash(redeemScript, net)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create P2SH address: %w", err)
+		}
+		return txscript.PayToAddrScript(addr)
+	default:
+		return nil, fmt.Errorf("unsupported descriptor type %q for scriptPubKey derivation", d.Type)
+	}
+}
+
+// deriveTaprootScriptPubKey derives the P2TR scriptPubKey for a tr()
+// descriptor: key-path-only (BIP-86 tweak, no script tree) if Multi is
+// nil, or script-path (single multi_a()/sortedmulti_a() tapscript leaf)
+// otherwise.
+func (d *Descriptor) deriveTaprootScriptPubKey(index uint32, net *chaincfg.Params) ([]byte, error) {
+	internalKeyBytes, err := d.InternalKey.DerivePubKey(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive internal key: %w", err)
+	}
+	internalKey, err := parseXOnlyOrCompressed(internalKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid internal key: %w", err)
+	}
+
+	if d.Multi == nil {
+		outputKey := txscript.ComputeTaprootKeyNoScript(internalKey)
+		addr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), net)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create P2TR address: %w", err)
+		}
+		return txscript.PayToAddrScript(addr)
+	}
+
+	leafPubKeys, err := d.deriveTapscriptLeafKeys(index)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := taproot.MultisigLeafScript(leafPubKeys, d.Multi.Threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build multisig leaf script: %w", err)
+	}
+	tree := txscript.AssembleTaprootScriptTree(leaf)
+	rootHash := tree.RootNode.TapHash()
+
+	outputKey := txscript.ComputeTaprootOutputKey(internalKey, rootHash[:])
+	addr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), net)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create P2TR address: %w", err)
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+// deriveTapscriptLeafKeys derives every key in d.Multi at index as
+// x-only tapscript-leaf pubkeys, sorted lexicographically (by x-only
+// serialization) when Multi.Sorted is set, matching sortedmulti_a()'s
+// BIP-387 ordering.
+func (d *Descriptor) deriveTapscriptLeafKeys(index uint32) ([]*btcec.PublicKey, error) {
+	var pubKeys []*btcec.PublicKey
+	for _, k := range d.Multi.Keys {
+		raw, err := k.DerivePubKey(index)
+		if err != nil {
+			return nil, err
+		}
+		pubKey, err := parseXOnlyOrCompressed(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid leaf key: %w", err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	if d.Multi.Sorted {
+		sort.Slice(pubKeys, func(i, j int) bool {
+			return bytes.Compare(
+				schnorr.SerializePubKey(pubKeys[i]),
+				schnorr.SerializePubKey(pubKeys[j]),
+			) < 0
+		})
+	}
+
+	return pubKeys, nil
+}
+
+// parseXOnlyOrCompressed parses raw as a 32-byte x-only (BIP-340) or
+// 33-byte compressed public key, depending on its length.
+func parseXOnlyOrCompressed(raw []byte) (*btcec.PublicKey, error) {
+	switch len(raw) {
+	case 32:
+		return schnorr.ParsePubKey(raw)
+	case 33:
+		return btcec.ParsePubKey(raw)
+	default:
+		return nil, fmt.Errorf("invalid public key length %d", len(raw))
+	}
+}
+
+// DeriveAddressPubKeys derives every key in d.Multi at index. For
+// sortedmulti(), the result is sorted lexicographically by serialized
+// compressed pubkey, as BIP-380 requires, so the script this tool builds
+// matches what Bitcoin Core, Sparrow, Electrum and HWI derive from the
+// same descriptor string; for multi(), descriptor order is preserved.
+func (d *Descriptor) DeriveAddressPubKeys(index uint32, net *chaincfg.Params) ([]*btcutil.AddressPubKey, error) {
+	var addrPubKeys []*btcutil.AddressPubKey
+	for _, k := range d.Multi.Keys {
+		pubKey, err := k.DerivePubKey(index)
+		if err != nil {
+			return nil, err
+		}
+		addrPubKey, err := btcutil.NewAddressPubKey(pubKey, net)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AddressPubKey: %w", err)
+		}
+		addrPubKeys = append(addrPubKeys, addrPubKey)
+	}
+
+	if d.Multi.Sorted {
+		sort.Slice(addrPubKeys, func(i, j int) bool {
+			return bytes.Compare(
+				addrPubKeys[i].ScriptAddress(), addrPubKeys[j].ScriptAddress(),
+			) < 0
+		})
+	}
+
+	return addrPubKeys, nil
+}
+
+// RedeemScript builds the P2WSH/P2SH redeem script for d at index. It
+// does not apply to tr(): a tapscript leaf is witness data pushed
+// directly, not hashed into the scriptPubKey the way a P2WSH/P2SH
+// redeem script is.
+func (d *Descriptor) RedeemScript(index uint32, net *chaincfg.Params) ([]byte, error) {
+	if d.Type == TR {
+		return nil, fmt.Errorf("tr() has no redeem script")
+	}
+
+	addrPubKeys, err := d.DeriveAddressPubKeys(index, net)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.MultiSigScript(addrPubKeys, d.Multi.Threshold)
+}
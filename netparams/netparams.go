@@ -0,0 +1,27 @@
+// Package netparams maps the -network flag used across the gen, sign,
+// create-unsigned and verify-signed binaries to the corresponding
+// chaincfg.Params, so every binary accepts the same four network names.
+package netparams
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Parse returns the chaincfg.Params for name, one of "mainnet", "testnet",
+// "signet" or "regtest". An empty name defaults to mainnet.
+func Parse(name string) (*chaincfg.Params, error) {
+	switch name {
+	case "", "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet":
+		return &chaincfg.TestNet3Params, nil
+	case "signet":
+		return &chaincfg.SigNetParams, nil
+	case "regtest":
+		return &chaincfg.RegressionNetParams, nil
+	default:
+		return nil, fmt.Errorf("unknown -network %q (want mainnet, testnet, signet or regtest)", name)
+	}
+}
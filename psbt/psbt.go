@@ -0,0 +1,257 @@
+// Package psbt provides the BIP-174 plumbing shared by the generator,
+// signer and finalizer binaries: building an unsigned PSBT for a P2WSH
+// multisig spend, attaching partial signatures, merging per-cosigner
+// PSBTs and finalizing the result into a network transaction.
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Bip32Derivation describes the derivation path for a single cosigner's
+// key that is embedded in a PSBT input so that signers (and hardware
+// wallets) know which key to use without a side-channel file.
+type Bip32Derivation struct {
+	MasterFingerprint uint32
+	Pubkey            []byte
+	Path              []uint32
+}
+
+// InputSpec describes one input to add to an unsigned PSBT: the outpoint
+// being spent, the amount and scriptPubKey of the coin (stored as a
+// WITNESS_UTXO), the witness script satisfying it, and the BIP32
+// derivation info for every cosigner key involved.
+type InputSpec struct {
+	OutPoint     wire.OutPoint
+	Amount       int64
+	PkScript     []byte
+	RedeemScript []byte
+	Derivations  []Bip32Derivation
+}
+
+// OutputSpec describes a single transaction output.
+type OutputSpec struct {
+	PkScript []byte
+	Amount   int64
+}
+
+// New builds an unsigned PSBT covering every input in inputs, populating
+// WITNESS_UTXO, WITNESS_SCRIPT and BIP32_DERIVATION for each one so that
+// any BIP-174 compatible signer can participate without out-of-band
+// redeem-script or derivation-path information.
+func New(inputs []InputSpec, outputs []OutputSpec) (*psbt.Packet, error) {
+	tx := wire.NewMsgTx(2)
+	for _, in := range inputs {
+		op := in.OutPoint
+		tx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+	}
+	for _, out := range outputs {
+		tx.AddTxOut(wire.NewTxOut(out.Amount, out.PkScript))
+	}
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PSBT: %w", err)
+	}
+
+	for i, in := range inputs {
+		packet.Inputs[i].WitnessUtxo = wire.NewTxOut(in.Amount, in.PkScript)
+		packet.Inputs[i].WitnessScript = in.RedeemScript
+		packet.Inputs[i].SighashType = txscript.SigHashAll
+
+		for _, d := range in.Derivations {
+			packet.Inputs[i].Bip32Derivation = append(
+				packet.Inputs[i].Bip32Derivation,
+				&psbt.Bip32Derivation{
+					PubKey:               d.Pubkey,
+					MasterKeyFingerprint: d.MasterFingerprint,
+					Bip32Path:            d.Path,
+				},
+			)
+		}
+	}
+
+	return packet, nil
+}
+
+// PrevOutFetcher builds a txscript.PrevOutputFetcher from a PSBT's
+// WITNESS_UTXO fields, so that sighashes can be computed without a
+// separate UTXO lookup.
+func PrevOutFetcher(packet *psbt.Packet) (txscript.PrevOutputFetcher, error) {
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, in := range packet.Inputs {
+		if in.WitnessUtxo == nil {
+			return nil, fmt.Errorf("input %d missing witness utxo", i)
+		}
+		fetcher.AddPrevOut(packet.UnsignedTx.TxIn[i].PreviousOutPoint, in.WitnessUtxo)
+	}
+	return fetcher, nil
+}
+
+// AddPartialSig inserts a cosigner's signature for the given input, keyed
+// by pubkey so the finalizer can later assemble the witness stack in the
+// order the redeem script expects regardless of signing order.
+func AddPartialSig(packet *psbt.Packet, inputIndex int, pubkey, sig []byte) error {
+	if inputIndex >= len(packet.Inputs) {
+		return fmt.Errorf("input index %d out of range", inputIndex)
+	}
+	packet.Inputs[inputIndex].PartialSigs = append(
+		packet.Inputs[inputIndex].PartialSigs,
+		&psbt.PartialSig{PubKey: pubkey, Signature: sig},
+	)
+	return nil
+}
+
+// Merge combines the partial signatures from several single-signer PSBTs
+// that all cover the same unsigned transaction into one packet ready for
+// finalization. btcutil/psbt has no merge function of its own, so this
+// unions each input's PartialSigs and Bip32Derivation across packets
+// directly, keyed by pubkey so a signature or derivation entry present in
+// more than one packet (e.g. echoed back unchanged by a cosigner) isn't
+// duplicated.
+func Merge(packets ...*psbt.Packet) (*psbt.Packet, error) {
+	if len(packets) == 0 {
+		return nil, fmt.Errorf("no packets to merge")
+	}
+
+	merged := packets[0]
+	mergedTxHex, err := txHex(merged)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range packets[1:] {
+		txHex, err := txHex(p)
+		if err != nil {
+			return nil, err
+		}
+		if txHex != mergedTxHex {
+			return nil, fmt.Errorf("cannot merge PSBTs covering different unsigned transactions")
+		}
+		if len(p.Inputs) != len(merged.Inputs) {
+			return nil, fmt.Errorf("cannot merge PSBTs with differing input counts")
+		}
+
+		for i := range merged.Inputs {
+			merged.Inputs[i].PartialSigs = mergePartialSigs(
+				merged.Inputs[i].PartialSigs, p.Inputs[i].PartialSigs,
+			)
+			merged.Inputs[i].Bip32Derivation = mergeBip32Derivations(
+				merged.Inputs[i].Bip32Derivation, p.Inputs[i].Bip32Derivation,
+			)
+		}
+	}
+	return merged, nil
+}
+
+// txHex serializes packet's unsigned transaction so two packets can be
+// compared for equality before merging their signatures.
+func txHex(packet *psbt.Packet) (string, error) {
+	var buf bytes.Buffer
+	if err := packet.UnsignedTx.Serialize(&buf); err != nil {
+		return "", fmt.Errorf("failed to serialize unsigned tx: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// mergePartialSigs unions a and b, keyed by pubkey.
+func mergePartialSigs(a, b []*psbt.PartialSig) []*psbt.PartialSig {
+	merged := append([]*psbt.PartialSig{}, a...)
+	for _, sig := range b {
+		found := false
+		for _, existing := range merged {
+			if bytes.Equal(existing.PubKey, sig.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, sig)
+		}
+	}
+	return merged
+}
+
+// mergeBip32Derivations unions a and b, keyed by pubkey.
+func mergeBip32Derivations(a, b []*psbt.Bip32Derivation) []*psbt.Bip32Derivation {
+	merged := append([]*psbt.Bip32Derivation{}, a...)
+	for _, deriv := range b {
+		found := false
+		for _, existing := range merged {
+			if bytes.Equal(existing.PubKey, deriv.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, deriv)
+		}
+	}
+	return merged
+}
+
+// Finalize runs the per-input witness finalizer over packet and extracts
+// the resulting network-serializable transaction.
+func Finalize(packet *psbt.Packet) (*wire.MsgTx, error) {
+	for i := range packet.Inputs {
+		if err := psbt.Finalize(packet, i); err != nil {
+			return nil, fmt.Errorf("failed to finalize input %d: %w", i, err)
+		}
+	}
+
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// Decode parses a base64-encoded PSBT, the form used both on disk and
+// over the wire between the coordinator and its cosigners.
+func Decode(data []byte) (*psbt.Packet, error) {
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(data), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PSBT: %w", err)
+	}
+	return packet, nil
+}
+
+// Encode serializes packet to its base64-encoded form.
+func Encode(packet *psbt.Packet) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize PSBT: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
+	return []byte(encoded), nil
+}
+
+// ReadFile loads a base64-encoded PSBT from disk.
+func ReadFile(path string) (*psbt.Packet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PSBT file: %w", err)
+	}
+	return Decode(data)
+}
+
+// WriteFile writes packet to disk as a base64-encoded PSBT, the standard
+// text representation used by Bitcoin Core, Sparrow, Electrum and
+// hardware wallets.
+func WriteFile(path string, packet *psbt.Packet) error {
+	encoded, err := Encode(packet)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, encoded, 0644); err != nil {
+		return fmt.Errorf("failed to write PSBT file: %w", err)
+	}
+	return nil
+}
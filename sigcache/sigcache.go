@@ -0,0 +1,107 @@
+// Package sigcache does NOT implement its own bounded map with
+// randomized eviction: Cache is a type alias for btcd's own
+// txscript.SigCache, and every function here is a thin wrapper around
+// its Exists/Add methods. That is a deliberate choice, not an oversight:
+// txscript.NewEngine only accepts a concrete *txscript.SigCache, so a
+// separate, independently-implemented cache here could never be wired
+// into script-engine verification, and verify-signed would be back to
+// double-checking every signature (once in the engine, once in this
+// package) with no way to actually skip the second elliptic-curve check.
+// Reusing txscript.SigCache lets the exact same cache instance be passed
+// to both txscript.NewEngine and the helpers below, so a signature only
+// ever pays for the expensive check once.
+//
+// VerifyBatch below is, correspondingly, not a real batch-verification
+// routine: it is a serial loop over Cache.Exists/Add. See its doc
+// comment for why.
+package sigcache
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// Cache is a concurrent-safe, bounded cache of verified signatures, keyed
+// by the triple (sighash, signature, pubkey). It is txscript.SigCache
+// itself so the same cache instance can be wired into txscript.NewEngine
+// and consulted here.
+type Cache = txscript.SigCache
+
+// New returns a Cache that holds at most maxEntries verified signatures.
+func New(maxEntries uint) *Cache {
+	return txscript.NewSigCache(maxEntries)
+}
+
+// VerifyECDSA verifies sig over sigHash with pubKey, consulting and
+// updating cache so repeat checks of the same triple are free.
+func VerifyECDSA(cache *Cache, sigHash chainhash.Hash, sig *ecdsa.Signature, pubKey *btcec.PublicKey) bool {
+	sigBytes := sig.Serialize()
+	pubKeyBytes := pubKey.SerializeCompressed()
+
+	if cache.Exists(sigHash, sigBytes, pubKeyBytes) {
+		return true
+	}
+
+	if !sig.Verify(sigHash[:], pubKey) {
+		return false
+	}
+
+	cache.Add(sigHash, sigBytes, pubKeyBytes)
+	return true
+}
+
+// SchnorrBatchItem is one Schnorr (sighash, signature, pubkey) triple to
+// verify as part of a batch, e.g. one key-path spend within a set of
+// transactions being checked together.
+type SchnorrBatchItem struct {
+	SigHash chainhash.Hash
+	Sig     *schnorr.Signature
+	PubKey  *btcec.PublicKey
+}
+
+// VerifyBatch does not perform real batch cryptography: it is a serial
+// loop that calls VerifySchnorr (cache lookup, falling back to a single
+// Verify) on each item in turn and returns the first failure. There is
+// no amortized, whole-batch elliptic-curve operation here — btcec/v2's
+// public schnorr package exposes no such API, unlike libsecp256k1's
+// native batch verification. The name describes the call shape ("verify
+// this batch of items"), not the algorithm used.
+//
+// The practical saving instead comes entirely from cache reuse: if cache
+// is the same *txscript.SigCache instance passed to txscript.NewEngine
+// for these transactions' own script verification, every item here was
+// already verified and cached there, so this loop degrades to cheap
+// Exists lookups rather than repeating the elliptic-curve work.
+func VerifyBatch(cache *Cache, items []SchnorrBatchItem) error {
+	for i, item := range items {
+		if !VerifySchnorr(cache, item.SigHash, item.Sig, item.PubKey) {
+			return fmt.Errorf("batch verification failed at item %d", i)
+		}
+	}
+	return nil
+}
+
+// VerifySchnorr verifies a BIP-340 Schnorr sig over sigHash with pubKey,
+// consulting and updating cache so repeat checks of the same triple are
+// free. The pubkey is keyed by its 32-byte x-only serialization, the same
+// form txscript's taproot verifier stores in the cache.
+func VerifySchnorr(cache *Cache, sigHash chainhash.Hash, sig *schnorr.Signature, pubKey *btcec.PublicKey) bool {
+	sigBytes := sig.Serialize()
+	pubKeyBytes := schnorr.SerializePubKey(pubKey)
+
+	if cache.Exists(sigHash, sigBytes, pubKeyBytes) {
+		return true
+	}
+
+	if !sig.Verify(sigHash[:], pubKey) {
+		return false
+	}
+
+	cache.Add(sigHash, sigBytes, pubKeyBytes)
+	return true
+}
@@ -0,0 +1,156 @@
+// Package slip132 recognizes the non-standard extended key version bytes
+// defined in SLIP-132 (ypub/zpub/Ypub/Zpub and their testnet/private
+// counterparts) and rewrites them to the canonical xpub/xprv or
+// tpub/tprv bytes that github.com/btcsuite/btcd/btcutil/hdkeychain
+// understands, while remembering which script type the original prefix
+// implied.
+package slip132
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+)
+
+// ScriptType is the spending policy implied by a SLIP-132 key prefix.
+type ScriptType int
+
+const (
+	// Unknown means the key used a standard xpub/xprv/tpub/tprv prefix,
+	// which carries no script-type hint of its own.
+	Unknown ScriptType = iota
+	P2PKH
+	P2SHP2WPKH
+	P2WPKH
+	P2SHP2WSH
+	P2WSH
+)
+
+func (t ScriptType) String() string {
+	switch t {
+	case P2PKH:
+		return "p2pkh"
+	case P2SHP2WPKH:
+		return "p2sh-p2wpkh"
+	case P2WPKH:
+		return "p2wpkh"
+	case P2SHP2WSH:
+		return "p2sh-p2wsh"
+	case P2WSH:
+		return "p2wsh"
+	default:
+		return "unknown"
+	}
+}
+
+// versionInfo describes one SLIP-132 (or standard BIP-32) version prefix:
+// the script type it implies, and the canonical version bytes hdkeychain
+// understands for that key's network family.
+type versionInfo struct {
+	scriptType ScriptType
+	canonical  [4]byte
+}
+
+// xpubVersion/xprvVersion/tpubVersion/tprvVersion are the standard BIP-32
+// version bytes that github.com/btcsuite/btcd/btcutil/hdkeychain already
+// recognizes for mainnet and testnet/signet/regtest respectively.
+var (
+	xpubVersion = [4]byte{0x04, 0x88, 0xb2, 0x1e}
+	xprvVersion = [4]byte{0x04, 0x88, 0xad, 0xe4}
+	tpubVersion = [4]byte{0x04, 0x35, 0x87, 0xcf}
+	tprvVersion = [4]byte{0x04, 0x35, 0x83, 0x94}
+)
+
+// versions maps every recognized 4-byte version prefix to its
+// versionInfo. Values taken from SLIP-132.
+var versions = map[[4]byte]versionInfo{
+	// Mainnet.
+	{0x04, 0x88, 0xb2, 0x1e}: {Unknown, xpubVersion},
+	{0x04, 0x88, 0xad, 0xe4}: {Unknown, xprvVersion},
+	{0x04, 0x9d, 0x7c, 0xb2}: {P2SHP2WPKH, xpubVersion}, // ypub
+	{0x04, 0x9d, 0x78, 0x78}: {P2SHP2WPKH, xprvVersion}, // yprv
+	{0x04, 0xb2, 0x47, 0x46}: {P2WPKH, xpubVersion},     // zpub
+	{0x04, 0xb2, 0x43, 0x0c}: {P2WPKH, xprvVersion},     // zprv
+	{0x02, 0x95, 0xb4, 0x3f}: {P2SHP2WSH, xpubVersion},  // Ypub
+	{0x02, 0x95, 0xb0, 0x05}: {P2SHP2WSH, xprvVersion},  // Yprv
+	{0x02, 0xaa, 0x7e, 0xd3}: {P2WSH, xpubVersion},      // Zpub
+	{0x02, 0xaa, 0x7a, 0x99}: {P2WSH, xprvVersion},      // Zprv
+
+	// Testnet/signet/regtest.
+	{0x04, 0x35, 0x87, 0xcf}: {Unknown, tpubVersion},
+	{0x04, 0x35, 0x83, 0x94}: {Unknown, tprvVersion},
+	{0x04, 0x4a, 0x52, 0x62}: {P2SHP2WPKH, tpubVersion}, // upub
+	{0x04, 0x4a, 0x4e, 0x28}: {P2SHP2WPKH, tprvVersion}, // uprv
+	{0x04, 0x5f, 0x1c, 0xf6}: {P2WPKH, tpubVersion},     // vpub
+	{0x04, 0x5f, 0x18, 0xbc}: {P2WPKH, tprvVersion},     // vprv
+	{0x02, 0x42, 0x89, 0xef}: {P2SHP2WSH, tpubVersion},  // Upub
+	{0x02, 0x42, 0x85, 0xb5}: {P2SHP2WSH, tprvVersion},  // Uprv
+	{0x02, 0x57, 0x54, 0x83}: {P2WSH, tpubVersion},      // Vpub
+	{0x02, 0x57, 0x50, 0x48}: {P2WSH, tprvVersion},      // Vprv
+}
+
+// Canonicalize rewrites an extended key encoded with a SLIP-132 version
+// prefix (ypub, zpub, Ypub, Zpub, or their testnet/private counterparts)
+// to the equivalent standard xpub/xprv/tpub/tprv, which is what
+// hdkeychain.NewKeyFromString expects. It also returns the ScriptType the
+// original prefix implied, so callers that care (e.g. when choosing how
+// to spend) don't lose that information. Keys already using a standard
+// prefix are returned unchanged with ScriptType Unknown.
+func Canonicalize(extKey string) (string, ScriptType, error) {
+	payload, err := decodePayload(extKey)
+	if err != nil {
+		return "", Unknown, err
+	}
+
+	var version [4]byte
+	copy(version[:], payload[:4])
+
+	info, ok := versions[version]
+	if !ok {
+		return "", Unknown, fmt.Errorf("unrecognized extended key version %x", version)
+	}
+	if version == info.canonical {
+		return extKey, info.scriptType, nil
+	}
+
+	rewritten := append([]byte{}, payload...)
+	copy(rewritten[0:4], info.canonical[:])
+
+	return encodeChecked(rewritten), info.scriptType, nil
+}
+
+// decodePayload base58check-decodes extKey and returns its full payload,
+// version bytes included (base58.Decode does not itself verify or strip
+// the checksum, so that's done here by hand rather than via
+// base58.CheckDecode, which assumes a 1-byte version rather than BIP-32's
+// 4-byte one).
+func decodePayload(extKey string) ([]byte, error) {
+	raw := base58.Decode(extKey)
+	if len(raw) < 4+4 {
+		return nil, fmt.Errorf("invalid extended key %q: too short", extKey)
+	}
+
+	payload := raw[:len(raw)-4]
+	checksum := raw[len(raw)-4:]
+	want := doubleSHA256(payload)[:4]
+	for i := range checksum {
+		if checksum[i] != want[i] {
+			return nil, fmt.Errorf("invalid extended key %q: bad checksum", extKey)
+		}
+	}
+	return payload, nil
+}
+
+// encodeChecked base58check-encodes a full BIP-32 payload (4-byte version
+// already included at the front).
+func encodeChecked(payload []byte) string {
+	checksum := doubleSHA256(payload)[:4]
+	return base58.Encode(append(payload, checksum...))
+}
+
+func doubleSHA256(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
@@ -0,0 +1,116 @@
+// Package taproot implements the P2TR key-path (MuSig2) and script-path
+// multisig constructions used as an alternative to P2WSH: cheaper to
+// spend and, on the key-path, indistinguishable from a single-sig spend.
+package taproot
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// AggregateKey runs BIP-327 MuSig2 key aggregation over pubKeys and
+// applies the BIP-341/BIP-86 key-path taptweak (no script-path merkle
+// root), yielding the x-only output key for a key-spend-only P2TR
+// address.
+func AggregateKey(pubKeys []*btcec.PublicKey) (*musig2.AggregateKey, error) {
+	aggKey, _, _, err := musig2.AggregateKeys(
+		pubKeys, true, musig2.WithBIP86KeyTweak(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate keys: %w", err)
+	}
+	return aggKey, nil
+}
+
+// KeyPathAddress derives the P2TR address spendable via the aggregated
+// MuSig2 key for pubKeys.
+func KeyPathAddress(pubKeys []*btcec.PublicKey, net *chaincfg.Params) (*btcutil.AddressTaproot, *musig2.AggregateKey, error) {
+	aggKey, err := AggregateKey(pubKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr, err := btcutil.NewAddressTaproot(
+		schnorr.SerializePubKey(aggKey.FinalKey), net,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create P2TR address: %w", err)
+	}
+
+	return addr, aggKey, nil
+}
+
+// Signer drives one cosigner's side of the two-round MuSig2 signing
+// protocol: generate a nonce (round 1), then, once every other
+// cosigner's nonce is known, produce a partial signature over msg
+// (round 2).
+type Signer struct {
+	session *musig2.Session
+	msg     [32]byte
+}
+
+// NewSigner starts a MuSig2 signing session for privKey against the
+// group of pubKeys (which must include privKey's public key) over msg,
+// applying the same BIP-86 key-path tweak used to derive the address.
+func NewSigner(privKey *btcec.PrivateKey, pubKeys []*btcec.PublicKey, msg [32]byte) (*Signer, error) {
+	ctx, err := musig2.NewContext(
+		privKey, true,
+		musig2.WithKnownSigners(pubKeys),
+		musig2.WithBip86TweakCtx(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create musig2 context: %w", err)
+	}
+
+	session, err := ctx.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start musig2 session: %w", err)
+	}
+	return &Signer{session: session, msg: msg}, nil
+}
+
+// PubNonce returns this cosigner's round-1 public nonce, to be shared
+// with every other cosigner (e.g. via nonces.json).
+func (s *Signer) PubNonce() [musig2.PubNonceSize]byte {
+	return s.session.PublicNonce()
+}
+
+// Sign registers every other cosigner's round-1 nonce and produces this
+// cosigner's round-2 partial signature.
+func (s *Signer) Sign(otherNonces [][musig2.PubNonceSize]byte) (*musig2.PartialSignature, error) {
+	for _, nonce := range otherNonces {
+		if _, err := s.session.RegisterPubNonce(nonce); err != nil {
+			return nil, fmt.Errorf("failed to register nonce: %w", err)
+		}
+	}
+
+	sig, err := s.session.Sign(s.msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to produce partial signature: %w", err)
+	}
+	return sig, nil
+}
+
+// CombineSigs aggregates every other cosigner's partial signature
+// (collected from partial_sigs.json) with this cosigner's own, which Sign
+// already recorded in the session, into the final 64-byte BIP-340
+// Schnorr signature that satisfies the P2TR key-path spend.
+func (s *Signer) CombineSigs(sigs []*musig2.PartialSignature) (*schnorr.Signature, error) {
+	for _, sig := range sigs {
+		_, err := s.session.CombineSig(sig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to combine partial signature: %w", err)
+		}
+	}
+
+	finalSig := s.session.FinalSig()
+	if finalSig == nil {
+		return nil, fmt.Errorf("not all partial signatures received")
+	}
+	return finalSig, nil
+}
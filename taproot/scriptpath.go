@@ -0,0 +1,106 @@
+package taproot
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// numsKeyHex is the x-only encoding of H = SHA256(uncompressed secp256k1
+// generator point G), the "nothing up my sleeve" internal key suggested
+// by BIP-341 for script-path-only outputs: nobody knows its discrete
+// log, so the key-path is provably unspendable.
+const numsKeyHex = "50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0"
+
+// NUMSInternalKey is the unspendable internal key used for our
+// script-path-only P2TR outputs.
+var NUMSInternalKey = mustParseNUMSKey(numsKeyHex)
+
+func mustParseNUMSKey(hexKey string) *btcec.PublicKey {
+	raw, err := hex.DecodeString(hexKey)
+	if err != nil {
+		panic(err)
+	}
+	key, err := schnorr.ParsePubKey(raw)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// MultisigLeafScript builds the <pk> OP_CHECKSIG <pk> OP_CHECKSIGADD ...
+// <m> OP_NUMEQUAL tapscript leaf that requires m-of-n BIP-340 Schnorr
+// signatures from pubKeys. Tapscript uses OP_CHECKSIGADD for this
+// instead of the legacy, non-Schnorr-compatible OP_CHECKMULTISIG.
+func MultisigLeafScript(pubKeys []*btcec.PublicKey, threshold int) (txscript.TapLeaf, error) {
+	if threshold <= 0 || threshold > len(pubKeys) {
+		return txscript.TapLeaf{}, fmt.Errorf("invalid threshold %d for %d keys", threshold, len(pubKeys))
+	}
+
+	builder := txscript.NewScriptBuilder()
+	for i, pk := range pubKeys {
+		builder.AddData(schnorr.SerializePubKey(pk))
+		if i == 0 {
+			builder.AddOp(txscript.OP_CHECKSIG)
+		} else {
+			builder.AddOp(txscript.OP_CHECKSIGADD)
+		}
+	}
+	builder.AddInt64(int64(threshold))
+	builder.AddOp(txscript.OP_NUMEQUAL)
+
+	script, err := builder.Script()
+	if err != nil {
+		return txscript.TapLeaf{}, fmt.Errorf("failed to build tapscript leaf: %w", err)
+	}
+
+	return txscript.NewBaseTapLeaf(script), nil
+}
+
+// ScriptPathAddress assembles a single-leaf tapscript tree from the
+// threshold multisig leaf, tweaks NUMSInternalKey with its merkle root
+// and returns the resulting P2TR address along with the tree needed to
+// build a control block for spending.
+func ScriptPathAddress(pubKeys []*btcec.PublicKey, threshold int, net *chaincfg.Params) (*btcutil.AddressTaproot, *txscript.IndexedTapScriptTree, *btcec.PublicKey, error) {
+	leaf, err := MultisigLeafScript(pubKeys, threshold)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tree := txscript.AssembleTaprootScriptTree(leaf)
+	rootHash := tree.RootNode.TapHash()
+
+	outputKey := txscript.ComputeTaprootOutputKey(NUMSInternalKey, rootHash[:])
+
+	addr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), net)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create P2TR address: %w", err)
+	}
+
+	return addr, tree, outputKey, nil
+}
+
+// ControlBlock builds the control block needed to spend tree's leaf at
+// leafIndex, given whether the tweaked output key has an odd Y
+// coordinate.
+func ControlBlock(tree *txscript.IndexedTapScriptTree, leafIndex int, outputKeyYIsOdd bool) ([]byte, error) {
+	proof := tree.LeafMerkleProofs[leafIndex]
+
+	cb := txscript.ControlBlock{
+		InternalKey:     NUMSInternalKey,
+		OutputKeyYIsOdd: outputKeyYIsOdd,
+		LeafVersion:     txscript.BaseLeafVersion,
+		InclusionProof:  proof.InclusionProof,
+	}
+
+	raw, err := cb.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize control block: %w", err)
+	}
+	return raw, nil
+}
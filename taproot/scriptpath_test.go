@@ -0,0 +1,61 @@
+package taproot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// TestScriptPathAddress exercises the whole p2tr-scriptpath construction
+// end to end: deriving the address, building the tapscript leaf and
+// control block. This is the concrete regression test for the
+// numsKeyHex truncation bug, which only surfaced at runtime (a panic in
+// this package's init()) and was invisible to `go build`/`go vet`.
+func TestScriptPathAddress(t *testing.T) {
+	var pubKeys []*btcec.PublicKey
+	for i := byte(1); i <= 3; i++ {
+		var seed [32]byte
+		seed[31] = i
+		priv, _ := btcec.PrivKeyFromBytes(seed[:])
+		pubKeys = append(pubKeys, priv.PubKey())
+	}
+
+	addr, tree, outputKey, err := ScriptPathAddress(pubKeys, 2, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("ScriptPathAddress: %v", err)
+	}
+	if addr == nil || addr.EncodeAddress() == "" {
+		t.Fatal("expected a non-empty P2TR address")
+	}
+
+	outputKeyYIsOdd := outputKey.SerializeCompressed()[0] == 0x03
+	cb, err := ControlBlock(tree, 0, outputKeyYIsOdd)
+	if err != nil {
+		t.Fatalf("ControlBlock: %v", err)
+	}
+	if len(cb) == 0 {
+		t.Fatal("expected a non-empty control block")
+	}
+}
+
+// TestNUMSInternalKey confirms the package-level NUMS internal key parses
+// to the expected x-only bytes, so a future edit to numsKeyHex that
+// breaks its length or encoding fails a test instead of panicking in
+// init() at first use.
+func TestNUMSInternalKey(t *testing.T) {
+	if NUMSInternalKey == nil {
+		t.Fatal("NUMSInternalKey is nil")
+	}
+
+	wantCompressed := []byte{
+		0x02, 0x50, 0x92, 0x9b, 0x74, 0xc1, 0xa0, 0x49, 0x54, 0xb7, 0x8b,
+		0x4b, 0x60, 0x35, 0xe9, 0x7a, 0x5e, 0x07, 0x8a, 0x5a, 0x0f, 0x28,
+		0xec, 0x96, 0xd5, 0x47, 0xbf, 0xee, 0x9a, 0xce, 0x80, 0x3a, 0xc0,
+	}
+	if !bytes.Equal(NUMSInternalKey.SerializeCompressed(), wantCompressed) {
+		t.Fatalf("unexpected NUMS key: got %x, want %x",
+			NUMSInternalKey.SerializeCompressed(), wantCompressed)
+	}
+}